@@ -4,56 +4,174 @@ import (
 	"fmt"
 	"io/ioutil"
 	"log"
+	"os"
 	"path/filepath"
+	"sort"
 	"strings"
+	"sync"
+	"text/tabwriter"
 	"time"
 
 	"github.com/99designs/api-sdk-go"
 	"github.com/urfave/cli"
 )
 
-func PrintList(uriMask string, olderThan time.Duration) {
+// ListOptions filters and sorts the remote files listed by ls
+type ListOptions struct {
+	URIMask   string
+	OlderThan time.Duration
+	NewerThan time.Duration
+	FileTypes []string
+	Sort      string
+	Limit     int
+	Long      bool
+}
+
+var listSorts = []string{"", "name", "uploaded", "size"}
+
+func PrintList(o ListOptions) {
+	// check before fetching what could be thousands of files
+	if !stringSlice(listSorts).contains(o.Sort) {
+		log.Fatalln("--sort must be name, uploaded or size")
+	}
+
 	req := smartling.FilesListRequest{
-		URIMask: uriMask,
+		URIMask: o.URIMask,
 	}
 
-	if olderThan > 0 {
-		req.LastUploadedBefore = smartling.UTC{Time: time.Now().Add(-olderThan)}
+	if o.OlderThan > 0 {
+		req.LastUploadedBefore = smartling.UTC{Time: time.Now().Add(-o.OlderThan)}
+	}
+	if o.NewerThan > 0 {
+		req.LastUploadedAfter = smartling.UTC{Time: time.Now().Add(-o.NewerThan)}
+	}
+	for _, ft := range o.FileTypes {
+		req.FileTypes = append(req.FileTypes, smartling.FileType(ft))
 	}
 
-	files, err := client.List(req)
+	files, err := client.ListAll(req)
 	logAndQuitIfError(err)
 
-	for _, f := range files.Items {
-		fmt.Println(f.FileURI)
+	// sorting by size needs the status of every file, otherwise only the
+	// files listed need it
+	var statuses map[string]*smartling.FileStatus
+	if o.Sort == "size" {
+		statuses = fetchFileStatuses(files)
+	}
+
+	switch o.Sort {
+	case "", "name":
+		sort.Slice(files, func(i, j int) bool { return files[i].FileURI < files[j].FileURI })
+	case "uploaded":
+		sort.Slice(files, func(i, j int) bool { return files[i].LastUploaded.Time.After(files[j].LastUploaded.Time) })
+	case "size":
+		sort.Slice(files, func(i, j int) bool {
+			return statuses[files[i].FileURI].TotalWordCount > statuses[files[j].FileURI].TotalWordCount
+		})
+	}
+
+	if o.Limit > 0 && len(files) > o.Limit {
+		files = files[:o.Limit]
+	}
+
+	if !o.Long {
+		for _, f := range files {
+			fmt.Println(f.FileURI)
+		}
+		return
+	}
+	if statuses == nil {
+		statuses = fetchFileStatuses(files)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	for _, f := range files {
+		st := statuses[f.FileURI]
+		fmt.Fprintf(w, "%s\t%d\t%d\t%s\t%s\n", f.FileType, st.TotalStringCount, st.TotalWordCount, f.LastUploaded.Time.Local().Format("2006-01-02 15:04"), f.FileURI)
 	}
+	w.Flush()
+}
+
+// fetchFileStatuses gets the status of each of the files concurrently
+func fetchFileStatuses(files []smartling.File) map[string]*smartling.FileStatus {
+	var mu sync.Mutex
+	statuses := map[string]*smartling.FileStatus{}
+
+	failed := forEachRemoteFile(files, "get the status of", "", func(f smartling.File) error {
+		st, err := client.Status(f.FileURI)
+		if err != nil {
+			return err
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		statuses[f.FileURI] = st
+		return nil
+	})
+	if failed > 0 {
+		os.Exit(1)
+	}
+
+	return statuses
+}
+
+func parseDurationFlag(c *cli.Context, name string) time.Duration {
+	if c.String(name) == "" {
+		return 0
+	}
+	d, err := time.ParseDuration(c.String(name))
+	logAndQuitIfError(err)
+
+	return d
 }
 
 var LsCommand = cli.Command{
 	Name:        "ls",
 	Usage:       "list remote files",
-	Description: "ls [<uriMask>]",
+	Description: "ls [-l] [<uriMask>]",
 	Flags: []cli.Flag{
+		cli.BoolFlag{
+			Name:  "l",
+			Usage: "Show the file type, string and word counts, and when it was last uploaded",
+		},
+		cli.StringFlag{
+			Name:  "older-than",
+			Usage: "Only files last uploaded longer ago than this, e.g. 720h",
+		},
+		cli.StringFlag{
+			Name:  "newer-than",
+			Usage: "Only files last uploaded more recently than this, e.g. 24h",
+		},
+		cli.StringSliceFlag{
+			Name:  "file-type",
+			Usage: "Only files of this type, can be repeated",
+		},
 		cli.StringFlag{
-			Name: "older-than",
+			Name:  "sort",
+			Value: "name",
+			Usage: "Sort by name, uploaded (newest first) or size (most words first)",
+		},
+		cli.IntFlag{
+			Name:  "limit",
+			Usage: "Show at most this many files",
 		},
 	},
 	Before: cmdBefore,
 	Action: func(c *cli.Context) {
 		if len(c.Args()) > 1 {
 			log.Println("Wrong number of arguments")
-			log.Fatalln("Usage: ls [<uriMask>]")
+			log.Fatalln("Usage: ls [-l] [<uriMask>]")
 		}
-		uriMask := c.Args().Get(0)
 
-		var d time.Duration
-		if len(c.String("older-than")) > 0 {
-			var err error
-			d, err = time.ParseDuration(c.String("older-than"))
-			logAndQuitIfError(err)
-		}
-
-		PrintList(uriMask, d)
+		PrintList(ListOptions{
+			URIMask:   c.Args().Get(0),
+			OlderThan: parseDurationFlag(c, "older-than"),
+			NewerThan: parseDurationFlag(c, "newer-than"),
+			FileTypes: c.StringSlice("file-type"),
+			Sort:      c.String("sort"),
+			Limit:     c.Int("limit"),
+			Long:      c.Bool("l"),
+		})
 	},
 }
 
@@ -73,24 +191,101 @@ func PrintFileStatus(remotepath, locale string) {
 	fmt.Println("File Type               ", f.FileType)
 }
 
+// PrintFileStatusTable prints the status of each file, with a row per
+// locale. If locales is empty all the locales are shown.
+func PrintFileStatusTable(files []smartling.File, locales []string) {
+	statuses := fetchFileStatuses(files)
+
+	for i, file := range files {
+		f := statuses[file.FileURI]
+		if i > 0 {
+			fmt.Println()
+		}
+		fmt.Println("File                    ", f.FileURI)
+		fmt.Println("String Count            ", f.TotalStringCount)
+		fmt.Println("Word Count              ", f.TotalWordCount)
+		fmt.Println("Last Uploaded           ", f.LastUploaded)
+		fmt.Println("File Type               ", f.FileType)
+		fmt.Println()
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', tabwriter.AlignRight)
+		fmt.Fprintln(w, "Locale\tAuthorized\tCompleted\tExcluded\tAuthorized Words\tCompleted Words\tExcluded Words\tComplete\t")
+		for _, fst := range f.Items {
+			if len(locales) > 0 && !stringSlice(locales).contains(fst.LocaleID) {
+				continue
+			}
+			fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%d\t%d\t%d\t%.0f%%\t\n",
+				fst.LocaleID,
+				fst.AuthorizedStringCount, fst.CompletedStringCount, fst.ExcludedStringCount,
+				fst.AuthorizedWordCount, fst.CompletedWordCount, fst.ExcludedWordCount,
+				percent(fst.CompletedStringCount, f.TotalStringCount-fst.ExcludedStringCount))
+		}
+		w.Flush()
+	}
+}
+
 var StatusCommand = cli.Command{
 	Name:        "stat",
-	Usage:       "display the translation status of a remote file",
-	Description: "stat <remote file> <locale>",
-	Before:      cmdBefore,
+	Usage:       "display the translation status of remote files",
+	Description: "stat [--locale <locale>]... <remote file>... | stat [--locale <locale>]... --mask <uriMask>",
+	Flags: []cli.Flag{
+		localeFlag,
+		cli.StringFlag{
+			Name:  "mask",
+			Usage: "Show the files matching this URI mask, where ** matches across directories",
+		},
+	},
+	Before: cmdBefore,
 	Action: func(c *cli.Context) {
-		if len(c.Args()) != 2 {
+		if (len(c.Args()) < 1) == (c.String("mask") == "") {
 			log.Println("Wrong number of arguments")
-			log.Fatalln("Usage: stat <remote file> <locale>")
+			log.Fatalln("Usage: stat [--locale <locale>]... <remote file>... | stat [--locale <locale>]... --mask <uriMask>")
 		}
 
-		remotepath := c.Args().Get(0)
-		locale := c.Args().Get(1)
+		args := c.Args()
+		locales := c.StringSlice("locale")
 
-		PrintFileStatus(remotepath, locale)
+		if len(locales) == 0 && isLegacyStatArgs(args) {
+			PrintFileStatus(args[0], args[1])
+			return
+		}
+
+		files := []smartling.File{}
+		for _, remotepath := range args {
+			files = append(files, smartling.File{FileURI: remotepath})
+		}
+		if c.String("mask") != "" {
+			files = remoteFilesMatching(c.String("mask"))
+			if len(files) == 0 {
+				log.Fatalln("No files match", c.String("mask"))
+			}
+		}
+
+		PrintFileStatusTable(files, locales)
 	},
 }
 
+// isLegacyStatArgs reports whether the args are the original form of stat,
+// stat <remote file> <locale>. That's when the second isn't an existing
+// local file but is one of the project's locales.
+func isLegacyStatArgs(args []string) bool {
+	if len(args) != 2 {
+		return false
+	}
+	if _, err := os.Stat(args[1]); err == nil {
+		return false
+	}
+
+	locales, err := client.Locales()
+	logAndQuitIfError(err)
+	for _, l := range locales {
+		if l.LocaleID == args[1] {
+			return true
+		}
+	}
+	return false
+}
+
 var GetCommand = cli.Command{
 	Name:        "get",
 	Usage:       "downloads a remote file",
@@ -178,6 +373,9 @@ var PutCommand = cli.Command{
 		})
 
 		logAndQuitIfError(err)
+		if dryRun {
+			return
+		}
 
 		fmt.Println("Overwritten: ", r.Overwritten)
 		fmt.Println("String Count:", r.StringCount)
@@ -207,17 +405,101 @@ var RenameCommand = cli.Command{
 
 var RmCommand = cli.Command{
 	Name:        "rm",
-	Usage:       "removes a remote file",
-	Description: "rm <remote file>...",
-	Before:      cmdBefore,
+	Usage:       "removes remote files",
+	Description: "rm <remote file>... | rm --mask <uriMask> [--yes]",
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "mask",
+			Usage: "Remove the files matching this URI mask, where ** matches across directories, e.g. /branch/old-*/**",
+		},
+		cli.BoolFlag{
+			Name:  "yes, y",
+			Usage: "Don't ask for confirmation before removing files matching the mask",
+		},
+	},
+	Before: cmdBefore,
 	Action: func(c *cli.Context) {
-		if len(c.Args()) < 1 {
+		if (len(c.Args()) < 1) == (c.String("mask") == "") {
 			log.Println("Wrong number of arguments")
-			log.Fatalln("Usage: rm <remote file>...")
+			log.Fatalln("Usage: rm <remote file>... | rm --mask <uriMask> [--yes]")
 		}
 
+		files := []smartling.File{}
 		for _, remotepath := range c.Args() {
-			logAndQuitIfError(client.Delete(remotepath))
+			files = append(files, smartling.File{FileURI: remotepath})
+		}
+
+		if c.String("mask") != "" {
+			files = remoteFilesMatching(c.String("mask"))
+			if len(files) == 0 {
+				fmt.Println("No files match", c.String("mask"))
+				return
+			}
+			for _, f := range files {
+				fmt.Println(f.FileURI)
+			}
+			if !c.Bool("yes") && !dryRun && !confirm(fmt.Sprintf("Remove these %d files?", len(files))) {
+				return
+			}
+		}
+
+		failed := forEachRemoteFile(files, "remove", "Removed", func(f smartling.File) error {
+			return client.Delete(f.FileURI)
+		})
+		if failed > 0 {
+			os.Exit(1)
+		}
+	},
+}
+
+var MvCommand = cli.Command{
+	Name:        "mv",
+	Usage:       "renames a remote file, or all the files in a remote directory",
+	Description: "mv <remote file or directory> <new remote file or directory>",
+	Before:      cmdBefore,
+	Action: func(c *cli.Context) {
+		if len(c.Args()) != 2 {
+			log.Println("Wrong number of arguments")
+			log.Fatalln("Usage: mv <remote file or directory> <new remote file or directory>")
+		}
+
+		src, dst := c.Args().Get(0), c.Args().Get(1)
+		files := remoteFilesUnder(src)
+		if len(files) == 0 {
+			log.Fatalln("No remote files found at", src)
+		}
+
+		failed := forEachRemoteFile(files, "move", "Moved", func(f smartling.File) error {
+			return client.Rename(f.FileURI, movedURI(f.FileURI, src, dst))
+		})
+		if failed > 0 {
+			os.Exit(1)
+		}
+	},
+}
+
+var CpCommand = cli.Command{
+	Name:        "cp",
+	Usage:       "copies a remote file, or all the files in a remote directory",
+	Description: "cp <remote file or directory> <new remote file or directory>",
+	Before:      cmdBefore,
+	Action: func(c *cli.Context) {
+		if len(c.Args()) != 2 {
+			log.Println("Wrong number of arguments")
+			log.Fatalln("Usage: cp <remote file or directory> <new remote file or directory>")
+		}
+
+		src, dst := c.Args().Get(0), c.Args().Get(1)
+		files := remoteFilesUnder(src)
+		if len(files) == 0 {
+			log.Fatalln("No remote files found at", src)
+		}
+
+		failed := forEachRemoteFile(files, "copy", "Copied", func(f smartling.File) error {
+			return copyRemoteFile(f, movedURI(f.FileURI, src, dst))
+		})
+		if failed > 0 {
+			os.Exit(1)
 		}
 	},
 }
@@ -267,3 +549,55 @@ var LocalesCommand = cli.Command{
 		}
 	},
 }
+
+var localeFlag = cli.StringSliceFlag{
+	Name:  "locale",
+	Usage: "Locale to use, can be repeated. Defaults to all locales",
+}
+
+func localesOrAll(c *cli.Context) []string {
+	if locales := c.StringSlice("locale"); len(locales) > 0 {
+		return locales
+	}
+	return fetchLocales()
+}
+
+var AuthorizeCommand = cli.Command{
+	Name:        "authorize",
+	Usage:       "authorizes a remote file for translation",
+	Description: "authorize [--locale <locale>]... <remote file>...",
+	Flags:       []cli.Flag{localeFlag},
+	Before:      cmdBefore,
+	Action: func(c *cli.Context) {
+		if len(c.Args()) < 1 {
+			log.Println("Wrong number of arguments")
+			log.Fatalln("Usage: authorize [--locale <locale>]... <remote file>...")
+		}
+
+		locales := localesOrAll(c)
+		for _, remotepath := range c.Args() {
+			logAndQuitIfError(client.Authorize(remotepath, locales))
+			fmt.Println("Authorized", remotepath)
+		}
+	},
+}
+
+var UnauthorizeCommand = cli.Command{
+	Name:        "unauthorize",
+	Usage:       "unauthorizes a remote file for translation",
+	Description: "unauthorize [--locale <locale>]... <remote file>...",
+	Flags:       []cli.Flag{localeFlag},
+	Before:      cmdBefore,
+	Action: func(c *cli.Context) {
+		if len(c.Args()) < 1 {
+			log.Println("Wrong number of arguments")
+			log.Fatalln("Usage: unauthorize [--locale <locale>]... <remote file>...")
+		}
+
+		locales := localesOrAll(c)
+		for _, remotepath := range c.Args() {
+			logAndQuitIfError(client.Unauthorize(remotepath, locales))
+			fmt.Println("Unauthorized", remotepath)
+		}
+	},
+}
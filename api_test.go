@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func statFakeSmartling(t *testing.T) *fakeSmartling {
+	f := newFakeProject(t, &Config{})
+	f.handle("GET", "/projects-api/v2/projects/project", func(r fakeRequest) interface{} {
+		return map[string]interface{}{
+			"sourceLocaleId": "en-US",
+			"targetLocales":  []map[string]interface{}{{"localeId": "de-DE", "enabled": true}},
+		}
+	})
+	f.handle("GET", "/files-api/v2/projects/project/file/status", func(r fakeRequest) interface{} {
+		return map[string]interface{}{
+			"fileUri":          r.Query.Get("fileUri"),
+			"totalStringCount": 10,
+			"items":            []map[string]interface{}{{"localeId": "de-DE", "completedStringCount": 5}},
+		}
+	})
+
+	return f
+}
+
+func TestStatLegacyForm(t *testing.T) {
+	statFakeSmartling(t)
+
+	out := runCommand(t, StatusCommand, "/a.json", "de-DE")
+	if !strings.Contains(out, "Completed String Count   5") {
+		t.Errorf("expected the single locale status, got:\n%s", out)
+	}
+}
+
+func TestStatSeveralFiles(t *testing.T) {
+	f := statFakeSmartling(t)
+
+	// a file named like a locale is still a file when it exists locally
+	chdir(t, t.TempDir())
+	writeTestFile(t, "de-DE", "")
+
+	for _, args := range [][]string{{"/a.json", "/b.json"}, {"/a.json", "de-DE"}} {
+		out := runCommand(t, StatusCommand, args...)
+		if strings.Count(out, "Locale") != 2 {
+			t.Errorf("stat %v: expected a table for each file, got:\n%s", args, out)
+		}
+	}
+	if n := len(f.Requests("GET", "/files-api/v2/projects/project/file/status")); n != 4 {
+		t.Errorf("got %d status requests, want 4", n)
+	}
+}
+
+func TestListFetchesStatusesOnlyForListedFiles(t *testing.T) {
+	uris := []string{}
+	for i := 0; i < 10; i++ {
+		uris = append(uris, fmt.Sprintf("/file%d.json", i))
+	}
+	f := newFakeProject(t, &Config{}, uris...)
+	status := "/files-api/v2/projects/project/file/status"
+	f.handle("GET", status, func(r fakeRequest) interface{} {
+		var n int
+		_, _ = fmt.Sscanf(r.Query.Get("fileUri"), "/file%d.json", &n)
+		return map[string]interface{}{"fileUri": r.Query.Get("fileUri"), "totalWordCount": n}
+	})
+
+	out := captureStdout(t, func() { PrintList(ListOptions{Long: true, Limit: 3}) })
+	if n := len(f.Requests("GET", status)); n != 3 {
+		t.Errorf("got %d status requests, want 3", n)
+	}
+	if !strings.Contains(out, "/file2.json") || strings.Contains(out, "/file3.json") {
+		t.Errorf("expected the first 3 files, got:\n%s", out)
+	}
+
+	out = captureStdout(t, func() { PrintList(ListOptions{Sort: "size", Limit: 2}) })
+	if n := len(f.Requests("GET", status)); n != 13 {
+		t.Errorf("got %d status requests, want every file's status to sort by size", n-3)
+	}
+	if out != "/file9.json\n/file8.json\n" {
+		t.Errorf("expected the 2 largest files, got:\n%s", out)
+	}
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/99designs/api-sdk-go"
+)
+
+// bulkConcurrency is how many remote files are changed at once
+const bulkConcurrency = 5
+
+// uriMaskRegexp converts a mask like /branch/old-*/** to a regexp, where *
+// matches within a path segment and ** matches across them. **/ also
+// matches no directories, so /a/**/b.json matches /a/b.json.
+func uriMaskRegexp(mask string) (*regexp.Regexp, error) {
+	var re strings.Builder
+	re.WriteString("^")
+	for i := 0; i < len(mask); i++ {
+		switch {
+		case strings.HasPrefix(mask[i:], "**/"):
+			re.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(mask[i:], "**"):
+			re.WriteString(".*")
+			i++
+		case mask[i] == '*':
+			re.WriteString("[^/]*")
+		case mask[i] == '?':
+			re.WriteString("[^/]")
+		default:
+			re.WriteString(regexp.QuoteMeta(mask[i : i+1]))
+		}
+	}
+	re.WriteString("$")
+
+	return regexp.Compile(re.String())
+}
+
+// remoteFilesMatching returns the remote files matching a URI mask
+func remoteFilesMatching(mask string) []smartling.File {
+	re, err := uriMaskRegexp(mask)
+	logAndQuitIfError(err)
+
+	files, err := client.ListAll(smartling.FilesListRequest{})
+	logAndQuitIfError(err)
+
+	matched := []smartling.File{}
+	for _, f := range files {
+		if re.MatchString(f.FileURI) {
+			matched = append(matched, f)
+		}
+	}
+	sort.Slice(matched, func(i, j int) bool { return matched[i].FileURI < matched[j].FileURI })
+
+	return matched
+}
+
+// remoteFilesUnder returns the remote files with the URI, or under it if
+// it's a directory
+func remoteFilesUnder(uri string) []smartling.File {
+	files, err := client.ListAll(smartling.FilesListRequest{})
+	logAndQuitIfError(err)
+
+	dir := strings.TrimSuffix(uri, "/") + "/"
+	matched := []smartling.File{}
+	for _, f := range files {
+		if f.FileURI == uri || strings.HasPrefix(f.FileURI, dir) {
+			matched = append(matched, f)
+		}
+	}
+	sort.Slice(matched, func(i, j int) bool { return matched[i].FileURI < matched[j].FileURI })
+
+	return matched
+}
+
+// movedURI returns the new URI of a file moved from src to dst, where src is
+// the file itself or a directory containing it
+func movedURI(uri, src, dst string) string {
+	if uri == src {
+		return dst
+	}
+	return strings.TrimSuffix(dst, "/") + "/" + strings.TrimPrefix(uri, strings.TrimSuffix(src, "/")+"/")
+}
+
+// confirm asks the user a yes/no question on stdin
+func confirm(question string) bool {
+	fmt.Printf("%s [y/N] ", question)
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+
+	return answer == "y" || answer == "yes"
+}
+
+// forEachRemoteFile runs f concurrently for the remote files, logging
+// progress and carrying on past failures. It returns the number that failed.
+// Successes aren't logged if pastTense is empty.
+func forEachRemoteFile(files []smartling.File, verb, pastTense string, f func(smartling.File) error) int {
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	done, failed := 0, 0
+
+	work := make(chan smartling.File)
+	for i := 0; i < bulkConcurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for file := range work {
+				err := f(file)
+
+				mu.Lock()
+				done++
+				if err != nil {
+					failed++
+					log.Printf("[%d/%d] Failed to %s %s: %s\n", done, len(files), verb, file.FileURI, err.Error())
+				} else if pastTense != "" && !dryRun {
+					log.Printf("[%d/%d] %s %s\n", done, len(files), pastTense, file.FileURI)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for _, file := range files {
+		work <- file
+	}
+	close(work)
+	wg.Wait()
+
+	if failed > 0 {
+		log.Printf("Failed to %s %d of %d files\n", verb, failed, len(files))
+	}
+
+	return failed
+}
+
+// copyRemoteFile downloads a remote file and uploads it with a new URI and
+// the parser config
+func copyRemoteFile(f smartling.File, newURI string) error {
+	b, err := client.Download(f.FileURI)
+	if err != nil {
+		return err
+	}
+
+	req := &smartling.FileUploadRequest{
+		FileURIRequest: smartling.FileURIRequest{FileURI: newURI},
+		FileType:       f.FileType,
+		File:           b,
+	}
+	// parse the copy the way project push parses the project's files
+	if ProjectConfig != nil {
+		req.Smartling.Directives = ProjectConfig.ParserConfig
+	}
+	_, err = client.Upload(req)
+	return err
+}
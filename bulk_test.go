@@ -0,0 +1,82 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/99designs/api-sdk-go"
+)
+
+func TestURIMaskRegexp(t *testing.T) {
+	tests := []struct {
+		mask    string
+		match   []string
+		noMatch []string
+	}{
+		{"/a.json", []string{"/a.json"}, []string{"/a.json.bak", "/x/a.json", "/aXjson"}},
+		{"/branch/*.json", []string{"/branch/a.json", "/branch/.json"}, []string{"/branch/sub/a.json", "/branch/a.yml"}},
+		{"/branch/old-*/**", []string{"/branch/old-1/a.json", "/branch/old-x/sub/b.json"}, []string{"/branch/new-1/a.json", "/branch/old-1"}},
+		{"/**/en.json", []string{"/en.json", "/a/en.json", "/a/b/en.json"}, []string{"/a/fr.json", "/a/xen.json"}},
+		{"/**", []string{"/", "/a", "/a/b/c.json"}, []string{"a"}},
+		{"/file?.json", []string{"/file1.json"}, []string{"/file.json", "/file12.json", "/file/.json"}},
+		{"/[a]+(b).json", []string{"/[a]+(b).json"}, []string{"/aa(b).json"}},
+	}
+
+	for _, tt := range tests {
+		re, err := uriMaskRegexp(tt.mask)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, uri := range tt.match {
+			if !re.MatchString(uri) {
+				t.Errorf("mask %s doesn't match %s", tt.mask, uri)
+			}
+		}
+		for _, uri := range tt.noMatch {
+			if re.MatchString(uri) {
+				t.Errorf("mask %s matches %s", tt.mask, uri)
+			}
+		}
+	}
+}
+
+func TestMovedURI(t *testing.T) {
+	tests := []struct {
+		uri, src, dst, want string
+	}{
+		{"/a.json", "/a.json", "/b.json", "/b.json"},
+		{"/old/a.json", "/old", "/new", "/new/a.json"},
+		{"/old/sub/a.json", "/old/", "/new/", "/new/sub/a.json"},
+		{"/old/a.json", "/old", "/", "/a.json"},
+	}
+
+	for _, tt := range tests {
+		if got := movedURI(tt.uri, tt.src, tt.dst); got != tt.want {
+			t.Errorf("movedURI(%s, %s, %s) = %s, want %s", tt.uri, tt.src, tt.dst, got, tt.want)
+		}
+	}
+}
+
+func TestCopyRemoteFileUsesTheParserConfig(t *testing.T) {
+	f := newFakeProject(t, &Config{ParserConfig: map[string]string{"placeholder_format_custom": `\{\w+\}`}})
+	f.handle("GET", "/files-api/v2/projects/project/file", func(r fakeRequest) interface{} {
+		return []byte(`{"a": "A {name}"}`)
+	})
+	upload := "/files-api/v2/projects/project/file"
+	f.handle("POST", upload, func(r fakeRequest) interface{} {
+		return map[string]interface{}{"stringCount": 1}
+	})
+
+	if err := copyRemoteFile(smartling.File{FileURI: "/a.json", FileType: smartling.FileTypeJSON}, "/b.json"); err != nil {
+		t.Fatal(err)
+	}
+
+	rr := f.Requests("POST", upload)
+	if len(rr) != 1 {
+		t.Fatalf("got %d uploads, want 1", len(rr))
+	}
+	body := string(rr[0].Body)
+	if !strings.Contains(body, "smartling.placeholder_format_custom") || !strings.Contains(body, `\{\w+\}`) {
+		t.Errorf("expected the parser config in the upload, got:\n%s", body)
+	}
+}
@@ -3,16 +3,20 @@ package main
 import (
 	"bytes"
 	"errors"
+	"fmt"
 	"io/ioutil"
 	"log"
 	"os"
 	"os/exec"
 	"os/user"
+	"path"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/99designs/api-sdk-go"
+	"github.com/urfave/cli"
 	"gopkg.in/yaml.v2"
 )
 
@@ -23,32 +27,126 @@ var defaultPullDestination = "{{ TrimSuffix .Path .Ext }}.{{.Locale}}{{.Ext}}"
 
 type Config struct {
 	path         string
-	ApiKey       string             `yaml:"api_key"`
-	UserID       string             `yaml:"user_id"`
-	ProjectID    string             `yaml:"project_id"`
-	CacheMaxAge  string             `yaml:"cache_max_age"`
-	FileGlobs    []string           `yaml:"files"`
-	FileType     smartling.FileType `yaml:"file_type"`
-	ParserConfig map[string]string  `yaml:"parser_config"`
-	PullFilePath string             `yaml:"pull_file_path"`
+	name         string
+	ApiKey       string              `yaml:"api_key,omitempty"`
+	UserID       string              `yaml:"user_id,omitempty"`
+	ProjectID    string              `yaml:"project_id,omitempty"`
+	CacheMaxAge  string              `yaml:"cache_max_age,omitempty"`
+	FileGlobs    []string            `yaml:"files,omitempty"`
+	FileType     smartling.FileType  `yaml:"file_type,omitempty"`
+	ParserConfig map[string]string   `yaml:"parser_config,omitempty"`
+	PullFilePath string              `yaml:"pull_file_path,omitempty"`
+	Status       StatusThresholds    `yaml:"status,omitempty"`
+	Rates        map[string]float64  `yaml:"rates,omitempty"`
+	GlossaryUID  string              `yaml:"glossary_uid,omitempty"`
+	Context      []ContextConfig     `yaml:"context,omitempty"`
+	Locales      []string            `yaml:"locales,omitempty"`
+	Projects     map[string]*Config  `yaml:"projects,omitempty"`
+	Includes     []string            `yaml:"include,omitempty"`
+	Overrides    string              `yaml:"overrides,omitempty"`
+	Fallbacks    map[string][]string `yaml:"fallbacks,omitempty"`
+	PullMode     string              `yaml:"pull_mode,omitempty"`
+	Formats      []FormatConfig      `yaml:"format,omitempty"`
 	hasGlobbed   bool
 	files        []string
 }
 
+// StatusThresholds are the translation completeness requirements checked by
+// `project status`
+type StatusThresholds struct {
+	RequireComplete *int           `yaml:"require_complete,omitempty"`
+	RequireLocale   map[string]int `yaml:"require_locale,omitempty"`
+	MaxAwaitingAuth *int           `yaml:"max_awaiting_auth,omitempty"`
+}
+
+const defaultConfigFile = "smartling.yml"
+
 var ErrConfigFileNotExist = errors.New("smartling.yml not found")
 
+// Files returns the project files matching the globs. Globs and the
+// returned paths are relative to the directory of the config file.
 func (c *Config) Files() []string {
 	if !c.hasGlobbed {
 		for _, g := range c.FileGlobs {
-			ff, err := filepath.Glob(g)
+			ff, err := filepath.Glob(filepath.Join(c.path, g))
 			logAndQuitIfError(err)
-			c.files = append(c.files, ff...)
+			for _, f := range ff {
+				rel, err := filepath.Rel(c.path, f)
+				logAndQuitIfError(err)
+				if !stringSlice(c.files).contains(filepath.ToSlash(rel)) {
+					c.files = append(c.files, filepath.ToSlash(rel))
+				}
+			}
 		}
+		c.hasGlobbed = true
 	}
 
 	return c.files
 }
 
+// project returns the config of one of the projects, with unset values
+// inherited from the top level
+func (c *Config) project(name string) (*Config, error) {
+	pc, ok := c.Projects[name]
+	if !ok {
+		return nil, fmt.Errorf("Project %s not found in the config", name)
+	}
+
+	p := *pc
+	p.path = c.path
+	p.name = name
+	p.Projects = nil
+	p.hasGlobbed = false
+	p.files = nil
+
+	inherit := func(v *string, parent string) {
+		if *v == "" {
+			*v = parent
+		}
+	}
+	inherit(&p.ApiKey, c.ApiKey)
+	inherit(&p.UserID, c.UserID)
+	inherit(&p.CacheMaxAge, c.CacheMaxAge)
+	inherit(&p.PullFilePath, c.PullFilePath)
+	inherit(&p.GlossaryUID, c.GlossaryUID)
+	inherit(&p.Overrides, c.Overrides)
+	inherit(&p.PullMode, c.PullMode)
+	if p.FileType == "" {
+		p.FileType = c.FileType
+	}
+	if p.ParserConfig == nil {
+		p.ParserConfig = c.ParserConfig
+	}
+	if p.Rates == nil {
+		p.Rates = c.Rates
+	}
+	if p.Locales == nil {
+		p.Locales = c.Locales
+	}
+	if p.Fallbacks == nil {
+		p.Fallbacks = c.Fallbacks
+	}
+	if p.Formats == nil {
+		p.Formats = c.Formats
+	}
+	if p.Status.RequireComplete == nil && p.Status.RequireLocale == nil && p.Status.MaxAwaitingAuth == nil {
+		p.Status = c.Status
+	}
+
+	return &p, nil
+}
+
+// projectNames returns the names of the projects, sorted
+func (c *Config) projectNames() []string {
+	names := []string{}
+	for name := range c.Projects {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
 func (c *Config) cacheMaxAge() time.Duration {
 	if c.CacheMaxAge != "" {
 		d, err := time.ParseDuration(c.CacheMaxAge)
@@ -59,6 +157,16 @@ func (c *Config) cacheMaxAge() time.Duration {
 	return time.Duration(4 * time.Hour)
 }
 
+// rate returns the cost per word of translating into a locale, falling back
+// to the "default" rate
+func (c *Config) rate(locale string) (float64, bool) {
+	if r, ok := c.Rates[locale]; ok {
+		return r, true
+	}
+	r, ok := c.Rates["default"]
+	return r, ok
+}
+
 func gitBranch() string {
 	cmd := exec.Command("git", "symbolic-ref", "--short", "HEAD")
 	var out bytes.Buffer
@@ -85,11 +193,56 @@ func pushPrefix() string {
 	return "/user/" + u.Username
 }
 
+// findConfigFile looks for smartling.yml in the current directory and its
+// parents, stopping at the root of the repository
+func findConfigFile() string {
+	wd, err := os.Getwd()
+	if err != nil {
+		return defaultConfigFile
+	}
+
+	for dir := wd; ; {
+		p := filepath.Join(dir, defaultConfigFile)
+		if _, err := os.Stat(p); err == nil {
+			rel, err := filepath.Rel(wd, p)
+			if err != nil {
+				return p
+			}
+			return rel
+		}
+
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			break
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+
+	return defaultConfigFile
+}
+
+// loadingConfigs guards against include cycles
+var loadingConfigs = map[string]bool{}
+
 func loadConfig(configfilepath string) (*Config, error) {
 	if _, err := os.Stat(configfilepath); err != nil {
 		return nil, ErrConfigFileNotExist
 	}
 
+	abs, err := filepath.Abs(configfilepath)
+	if err != nil {
+		return nil, err
+	}
+	if loadingConfigs[abs] {
+		return nil, fmt.Errorf("%s includes itself", configfilepath)
+	}
+	loadingConfigs[abs] = true
+	defer delete(loadingConfigs, abs)
+
 	b, err := ioutil.ReadFile(configfilepath)
 	if err != nil {
 		return nil, err
@@ -103,5 +256,104 @@ func loadConfig(configfilepath string) (*Config, error) {
 
 	c.path = filepath.Dir(configfilepath)
 
+	if err := interpolateConfig(&c); err != nil {
+		return nil, err
+	}
+
+	if err := c.loadIncludes(); err != nil {
+		return nil, err
+	}
+
 	return &c, nil
 }
+
+// loadIncludes adds the files of the included configs, which are relative to
+// the included config's directory
+func (c *Config) loadIncludes() error {
+	for _, g := range c.Includes {
+		ff, err := filepath.Glob(filepath.Join(c.path, g))
+		if err != nil {
+			return err
+		}
+		if len(ff) == 0 {
+			return fmt.Errorf("Included config %s not found", g)
+		}
+
+		for _, f := range ff {
+			inc, err := loadConfig(f)
+			if err != nil {
+				return fmt.Errorf("Error loading included %s: %s", f, err.Error())
+			}
+
+			dir, err := filepath.Rel(c.path, inc.path)
+			if err != nil {
+				return err
+			}
+			for _, fg := range inc.FileGlobs {
+				c.FileGlobs = append(c.FileGlobs, path.Join(filepath.ToSlash(dir), fg))
+			}
+		}
+	}
+
+	return nil
+}
+
+// masked returns a copy of the config with the API keys hidden
+func (c *Config) masked() *Config {
+	mask := func(s string) string {
+		if len(s) <= 4 {
+			return s
+		}
+		return strings.Repeat("*", len(s)-4) + s[len(s)-4:]
+	}
+
+	m := *c
+	m.ApiKey = mask(c.ApiKey)
+	if c.Projects != nil {
+		m.Projects = map[string]*Config{}
+		for name, pc := range c.Projects {
+			p := *pc
+			p.ApiKey = mask(pc.ApiKey)
+			m.Projects[name] = &p
+		}
+	}
+
+	return &m
+}
+
+var ConfigCommand = cli.Command{
+	Name:  "config",
+	Usage: "inspect the config file",
+	Subcommands: []cli.Command{
+		configShowCommand,
+	},
+}
+
+var configShowCommand = cli.Command{
+	Name:  "show",
+	Usage: "prints the config with environment variables resolved",
+	Action: func(c *cli.Context) {
+		if len(c.Args()) != 0 {
+			log.Println("Wrong number of arguments")
+			log.Fatalln("Usage: show")
+		}
+
+		configFile := c.GlobalString("configfile")
+		if configFile == "" {
+			configFile = findConfigFile()
+		}
+		cfg, err := loadConfig(configFile)
+		if err != nil {
+			log.Fatalf("Error loading %s: %s\n", configFile, err.Error())
+		}
+		if c.GlobalString("project") != "" {
+			cfg, err = cfg.project(c.GlobalString("project"))
+			logAndQuitIfError(err)
+		}
+
+		b, err := yaml.Marshal(cfg.masked())
+		logAndQuitIfError(err)
+		fmt.Println("# " + configFile)
+		fmt.Print(string(b))
+	},
+}
@@ -0,0 +1,316 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"mime/multipart"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/urfave/cli"
+)
+
+// ContextConfig maps a directory of screenshots or HTML pages to the project
+// files whose strings they show. Paths are relative to the config file.
+type ContextConfig struct {
+	FileGlobs   []string `yaml:"files"`
+	Screenshots string   `yaml:"screenshots"`
+}
+
+func (cc ContextConfig) matches(projectFilepath string) bool {
+	for _, g := range cc.FileGlobs {
+		if ok, _ := filepath.Match(g, projectFilepath); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Context is visual context uploaded to Smartling
+type Context struct {
+	ContextUID  string `json:"contextUid"`
+	ContextType string `json:"contextType"`
+	Name        string `json:"name"`
+}
+
+// ContextRegion is the area of an image context a string appears in
+type ContextRegion struct {
+	Top    int `json:"top"`
+	Left   int `json:"left"`
+	Width  int `json:"width"`
+	Height int `json:"height"`
+}
+
+type contextBinding struct {
+	ContextUID     string         `json:"contextUid"`
+	StringHashcode string         `json:"stringHashcode"`
+	Coordinates    *ContextRegion `json:"coordinates,omitempty"`
+}
+
+var contextFileExtensions = map[string]string{
+	".png":  "image/png",
+	".jpg":  "image/jpeg",
+	".jpeg": "image/jpeg",
+	".gif":  "image/gif",
+	".html": "text/html",
+	".htm":  "text/html",
+}
+
+func isContextFile(name string) bool {
+	_, ok := contextFileExtensions[strings.ToLower(path.Ext(name))]
+	return ok
+}
+
+func (c *FaultTolerantClient) contextEndpoint(path string) string {
+	return fmt.Sprintf("/context-api/v2/projects/%s%s", c.ProjectID, path)
+}
+
+func (c *FaultTolerantClient) UploadContext(name string, content []byte) (ctx *Context, err error) {
+	body := &bytes.Buffer{}
+	w := multipart.NewWriter(body)
+	fw, err := w.CreateFormFile("content", name)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = fw.Write(content); err != nil {
+		return nil, err
+	}
+	if err = w.WriteField("name", name); err != nil {
+		return nil, err
+	}
+	if err = w.Close(); err != nil {
+		return nil, err
+	}
+
+	c.execWithRetry(func() error {
+		ctx = &Context{}
+		err = c.request("POST", c.contextEndpoint("/contexts"), nil, body.Bytes(), w.FormDataContentType(), ctx)
+		return err
+	})
+	return
+}
+
+func (c *FaultTolerantClient) BindContext(bindings []contextBinding) (err error) {
+	c.execWithRetry(func() error {
+		err = c.requestJSON("POST", c.contextEndpoint("/bindings"), nil, map[string]interface{}{
+			"bindings": bindings,
+		}, nil)
+		return err
+	})
+	return
+}
+
+// MatchContext asks Smartling to find the strings of a remote file in the
+// context, using the HTML text or OCR for images
+func (c *FaultTolerantClient) MatchContext(contextUID, fileUri string) (err error) {
+	c.execWithRetry(func() error {
+		err = c.requestJSON("POST", c.contextEndpoint("/contexts/"+contextUID+"/match/async"), nil, map[string]interface{}{
+			"contentFileUri": fileUri,
+		}, nil)
+		return err
+	})
+	return
+}
+
+// StringHashcodesForKeys returns the hashcodes of the strings with the given
+// keys in a remote file
+func (c *FaultTolerantClient) StringHashcodesForKeys(fileUri string, keys []string) (hashcodes map[string]string, err error) {
+	c.execWithRetry(func() error {
+		hashcodes = map[string]string{}
+		for offset := 0; ; {
+			var r struct {
+				TotalCount int
+				Items      []struct {
+					Hashcode string
+					Keys     []struct {
+						Key     string
+						FileURI string `json:"fileUri"`
+					}
+				}
+			}
+			params := url.Values{"fileUri": {fileUri}, "offset": {fmt.Sprint(offset)}}
+			err = c.requestJSON("GET", c.stringsEndpoint("/source-strings"), params, nil, &r)
+			if err != nil {
+				return err
+			}
+
+			for _, item := range r.Items {
+				for _, k := range item.Keys {
+					if stringSlice(keys).contains(k.Key) {
+						hashcodes[k.Key] = item.Hashcode
+					}
+				}
+			}
+
+			offset += len(r.Items)
+			if len(r.Items) == 0 || offset >= r.TotalCount {
+				return nil
+			}
+		}
+	})
+	return
+}
+
+func parseContextRegion(s string) *ContextRegion {
+	if s == "" {
+		return nil
+	}
+
+	parts := strings.Split(s, ",")
+	if len(parts) != 4 {
+		log.Fatalln("region must be in the format --region=top,left,width,height")
+	}
+	n := []int{}
+	for _, p := range parts {
+		i, err := strconv.Atoi(strings.TrimSpace(p))
+		logAndQuitIfError(err)
+		n = append(n, i)
+	}
+
+	return &ContextRegion{Top: n[0], Left: n[1], Width: n[2], Height: n[3]}
+}
+
+// uploadContext uploads a screenshot or HTML page, and binds it to the given
+// keys of the remote file, or to any of its strings Smartling can match
+func uploadContext(localpath, remoteFile string, keys []string, region *ContextRegion) {
+	bindContext(uploadContextFile(localpath), localpath, remoteFile, keys, region)
+}
+
+func uploadContextFile(localpath string) *Context {
+	b, err := ioutil.ReadFile(localpath)
+	logAndQuitIfError(err)
+
+	ctx, err := client.UploadContext(path.Base(localpath), b)
+	logAndQuitIfError(err)
+	log.Println("Uploaded context", localpath)
+
+	return ctx
+}
+
+// bindContext binds uploaded context to the given keys of the remote file, or
+// to any of its strings Smartling can match
+func bindContext(ctx *Context, localpath, remoteFile string, keys []string, region *ContextRegion) {
+	if len(keys) == 0 {
+		logAndQuitIfError(client.MatchContext(ctx.ContextUID, remoteFile))
+		log.Println("Matching context", localpath, "with", remoteFile)
+		return
+	}
+
+	hashcodes, err := client.StringHashcodesForKeys(remoteFile, keys)
+	logAndQuitIfError(err)
+
+	bindings := []contextBinding{}
+	for _, k := range keys {
+		h, ok := hashcodes[k]
+		if !ok {
+			log.Println("Key", k, "not found in", remoteFile)
+			continue
+		}
+		bindings = append(bindings, contextBinding{
+			ContextUID:     ctx.ContextUID,
+			StringHashcode: h,
+			Coordinates:    region,
+		})
+	}
+	if len(bindings) > 0 {
+		logAndQuitIfError(client.BindContext(bindings))
+		log.Printf("Bound context %s to %d strings\n", localpath, len(bindings))
+	}
+}
+
+// uploadProjectContext uploads the context configured for the pushed project
+// files. Each screenshot is uploaded once and bound to all its files.
+func uploadProjectContext(pushed map[string]string) {
+	uploaded := map[string]*Context{}
+
+	for _, cc := range ProjectConfig.Context {
+		remoteFiles := []string{}
+		for projectFilepath, remoteFile := range pushed {
+			if cc.matches(projectFilepath) {
+				remoteFiles = append(remoteFiles, remoteFile)
+			}
+		}
+		if len(remoteFiles) == 0 {
+			continue
+		}
+		sort.Strings(remoteFiles)
+
+		dir := localRelativeFilePath(cc.Screenshots)
+		files, err := ioutil.ReadDir(dir)
+		if os.IsNotExist(err) {
+			log.Println("Skipping the screenshots in", dir, "as it doesn't exist")
+			continue
+		}
+		logAndQuitIfError(err)
+
+		for _, f := range files {
+			if f.IsDir() || !isContextFile(f.Name()) {
+				continue
+			}
+
+			localpath := filepath.Join(dir, f.Name())
+			ctx, ok := uploaded[localpath]
+			if !ok {
+				ctx = uploadContextFile(localpath)
+				uploaded[localpath] = ctx
+			}
+			for _, remoteFile := range remoteFiles {
+				bindContext(ctx, localpath, remoteFile, nil, nil)
+			}
+		}
+	}
+}
+
+var ContextCommand = cli.Command{
+	Name:   "context",
+	Usage:  "manage visual context for strings",
+	Before: cmdBefore,
+	Subcommands: []cli.Command{
+		contextUploadCommand,
+	},
+}
+
+var contextUploadCommand = cli.Command{
+	Name:        "upload",
+	Usage:       "uploads a screenshot or HTML page as context for the strings of a remote file",
+	Description: "upload --file <remote file> [--keys <key>,...] [--region <top>,<left>,<width>,<height>] <image|html>",
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "file",
+			Usage: "Remote file the strings are in",
+		},
+		cli.StringFlag{
+			Name:  "keys",
+			Usage: "Keys of the strings to bind. Defaults to any strings Smartling can match",
+		},
+		cli.StringFlag{
+			Name:  "region",
+			Usage: "Region of an image the strings appear in, as top,left,width,height",
+		},
+	},
+	Action: func(c *cli.Context) {
+		if len(c.Args()) != 1 || c.String("file") == "" {
+			log.Println("Wrong number of arguments")
+			log.Fatalln("Usage: upload --file <remote file> [--keys <key>,...] [--region <top>,<left>,<width>,<height>] <image|html>")
+		}
+
+		localpath := c.Args().Get(0)
+		if !isContextFile(localpath) {
+			log.Fatalln("Context must be an image or HTML file")
+		}
+
+		keys := []string{}
+		if c.String("keys") != "" {
+			keys = strings.Split(c.String("keys"), ",")
+		}
+
+		uploadContext(localpath, c.String("file"), keys, parseContextRegion(c.String("region")))
+	},
+}
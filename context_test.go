@@ -0,0 +1,67 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestUploadProjectContextUploadsEachScreenshotOnce(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, "screenshots"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"home.png", "cart.png", "notes.txt"} {
+		if err := ioutil.WriteFile(filepath.Join(dir, "screenshots", name), []byte("image"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	chdir(t, dir)
+
+	f := newFakeSmartling(t)
+	f.use(t, &Config{path: ".", Context: []ContextConfig{{FileGlobs: []string{"web/*.json"}, Screenshots: "screenshots"}}})
+	f.handle("POST", "/context-api/v2/projects/project/contexts", func(r fakeRequest) interface{} {
+		return map[string]string{"contextUid": "context"}
+	})
+	f.handle("POST", "/context-api/v2/projects/project/contexts/context/match/async", func(r fakeRequest) interface{} {
+		return nil
+	})
+
+	uploadProjectContext(map[string]string{
+		"web/a.json": "/a.json",
+		"web/b.json": "/b.json",
+		"app/c.json": "/c.json",
+	})
+
+	if n := len(f.Requests("POST", "/context-api/v2/projects/project/contexts")); n != 2 {
+		t.Errorf("got %d uploads, want one per screenshot", n)
+	}
+	if n := len(f.Requests("POST", "/context-api/v2/projects/project/contexts/context/match/async")); n != 4 {
+		t.Errorf("got %d matches, want each screenshot matched with each file", n)
+	}
+}
+
+func TestUploadProjectContextSkipsMissingScreenshots(t *testing.T) {
+	chdir(t, t.TempDir())
+
+	f := newFakeSmartling(t)
+	f.use(t, &Config{path: ".", Context: []ContextConfig{{FileGlobs: []string{"*.json"}, Screenshots: "screenshots"}}})
+
+	uploadProjectContext(map[string]string{"a.json": "/a.json"})
+
+	if len(f.requests) != 0 {
+		t.Errorf("got %d requests, want none", len(f.requests))
+	}
+}
+
+func TestContextDecodesName(t *testing.T) {
+	var ctx Context
+	if err := json.Unmarshal([]byte(`{"contextUid": "uid", "contextType": "IMAGE", "name": "home.png"}`), &ctx); err != nil {
+		t.Fatal(err)
+	}
+	if ctx.Name != "home.png" {
+		t.Errorf("got name %q, want home.png", ctx.Name)
+	}
+}
@@ -0,0 +1,315 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/99designs/api-sdk-go"
+	"gopkg.in/yaml.v3"
+)
+
+// translationFile is a parsed JSON or YAML translation file whose strings can
+// be changed. It's written back with the keys in their original order, and
+// for YAML with its comments.
+type translationFile struct {
+	fileType smartling.FileType
+	doc      *yaml.Node
+	indent   string
+}
+
+func parseTranslationFile(b []byte, ft smartling.FileType) (*translationFile, error) {
+	tf := &translationFile{fileType: ft, indent: "  "}
+
+	switch ft {
+	case smartling.FileTypeJSON:
+		dec := json.NewDecoder(bytes.NewReader(b))
+		dec.UseNumber()
+		n, err := decodeJSONNode(dec)
+		if err == io.EOF {
+			n, err = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		tf.doc = &yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{n}}
+	case smartling.FileTypeYAML:
+		var doc yaml.Node
+		if err := yaml.Unmarshal(b, &doc); err != nil {
+			return nil, err
+		}
+		if doc.Kind == 0 {
+			doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}}}
+		}
+		tf.doc = &doc
+	default:
+		return nil, fmt.Errorf("Can't parse translations of file type %s", ft)
+	}
+
+	return tf, nil
+}
+
+func (tf *translationFile) root() *yaml.Node {
+	return tf.doc.Content[0]
+}
+
+// Get returns the string at a dotted key, as flattened by parseTranslations
+func (tf *translationFile) Get(key string) (string, bool) {
+	n := lookupNode(tf.root(), key)
+	if n == nil || n.Kind != yaml.ScalarNode || n.ShortTag() != "!!str" {
+		return "", false
+	}
+	return n.Value, true
+}
+
+// Set changes the string at a dotted key, adding it if it doesn't exist
+func (tf *translationFile) Set(key, value string) {
+	if n := lookupNode(tf.root(), key); n != nil && n.Kind == yaml.ScalarNode {
+		n.Tag, n.Value = "!!str", value
+		return
+	}
+	setNode(tf.root(), key, value)
+}
+
+// hasParent reports whether the mapping a dotted key would be in exists
+func (tf *translationFile) hasParent(key string) bool {
+	i := strings.LastIndex(key, ".")
+	if i == -1 {
+		return true
+	}
+	n := lookupNode(tf.root(), key[:i])
+	return n != nil && n.Kind == yaml.MappingNode
+}
+
+// Keys returns the dotted keys of the strings, in the order they're in the
+// file
+func (tf *translationFile) Keys() []string {
+	keys := []string{}
+	walkStrings(tf.root(), "", func(key string, n *yaml.Node) {
+		keys = append(keys, key)
+	})
+	return keys
+}
+
+// Translations returns the strings keyed by their dotted keys
+func (tf *translationFile) Translations() map[string]string {
+	kv := map[string]string{}
+	walkStrings(tf.root(), "", func(key string, n *yaml.Node) {
+		kv[key] = n.Value
+	})
+	return kv
+}
+
+func (tf *translationFile) Bytes() ([]byte, error) {
+	buf := &bytes.Buffer{}
+
+	if tf.fileType == smartling.FileTypeJSON {
+		if err := encodeJSONNode(buf, tf.root(), tf.indent, 0); err != nil {
+			return nil, err
+		}
+		buf.WriteString("\n")
+		return buf.Bytes(), nil
+	}
+
+	enc := yaml.NewEncoder(buf)
+	enc.SetIndent(len(tf.indent))
+	if err := enc.Encode(tf.doc); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func joinKey(prefix, k string) string {
+	if prefix == "" {
+		return k
+	}
+	return prefix + "." + k
+}
+
+// lookupNode finds the node at a dotted key. Keys in the file may contain
+// dots, so each key that's a prefix of the dotted key is tried.
+func lookupNode(n *yaml.Node, key string) *yaml.Node {
+	switch n.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			k, v := n.Content[i].Value, n.Content[i+1]
+			if key == k {
+				return v
+			}
+			if strings.HasPrefix(key, k+".") {
+				if found := lookupNode(v, key[len(k)+1:]); found != nil {
+					return found
+				}
+			}
+		}
+	case yaml.SequenceNode:
+		seg, rest := key, ""
+		if i := strings.Index(key, "."); i != -1 {
+			seg, rest = key[:i], key[i+1:]
+		}
+		i, err := strconv.Atoi(seg)
+		if err != nil || i < 0 || i >= len(n.Content) {
+			return nil
+		}
+		if rest == "" {
+			return n.Content[i]
+		}
+		return lookupNode(n.Content[i], rest)
+	}
+
+	return nil
+}
+
+// setNode adds a string at a dotted key to a mapping, adding any mappings
+// needed for the parts of the key
+func setNode(n *yaml.Node, key, value string) {
+	if n.Kind != yaml.MappingNode {
+		return
+	}
+
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		k, v := n.Content[i].Value, n.Content[i+1]
+		if strings.HasPrefix(key, k+".") && v.Kind == yaml.MappingNode {
+			setNode(v, key[len(k)+1:], value)
+			return
+		}
+	}
+
+	seg, rest := key, ""
+	if i := strings.Index(key, "."); i != -1 {
+		seg, rest = key[:i], key[i+1:]
+	}
+	k := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: seg}
+	if rest == "" {
+		n.Content = append(n.Content, k, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value})
+		return
+	}
+	m := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	n.Content = append(n.Content, k, m)
+	setNode(m, rest, value)
+}
+
+// walkStrings calls f with each string in document order
+func walkStrings(n *yaml.Node, prefix string, f func(key string, n *yaml.Node)) {
+	switch n.Kind {
+	case yaml.DocumentNode:
+		for _, c := range n.Content {
+			walkStrings(c, prefix, f)
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			walkStrings(n.Content[i+1], joinKey(prefix, n.Content[i].Value), f)
+		}
+	case yaml.SequenceNode:
+		for i, c := range n.Content {
+			walkStrings(c, joinKey(prefix, fmt.Sprint(i)), f)
+		}
+	case yaml.ScalarNode:
+		if n.ShortTag() == "!!str" {
+			f(prefix, n)
+		}
+	}
+}
+
+// decodeJSONNode reads a JSON value into a node, keeping the order of the
+// keys of objects
+func decodeJSONNode(dec *json.Decoder) (*yaml.Node, error) {
+	t, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch v := t.(type) {
+	case json.Delim:
+		n := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		if v == '[' {
+			n = &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		}
+		for dec.More() {
+			if n.Kind == yaml.MappingNode {
+				k, err := dec.Token()
+				if err != nil {
+					return nil, err
+				}
+				n.Content = append(n.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: k.(string)})
+			}
+			c, err := decodeJSONNode(dec)
+			if err != nil {
+				return nil, err
+			}
+			n.Content = append(n.Content, c)
+		}
+		if _, err := dec.Token(); err != nil {
+			return nil, err
+		}
+		return n, nil
+	case string:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: v}, nil
+	case json.Number:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!float", Value: v.String()}, nil
+	case bool:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: strconv.FormatBool(v)}, nil
+	default:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null", Value: "null"}, nil
+	}
+}
+
+func encodeJSONString(buf *bytes.Buffer, s string) error {
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(s); err != nil {
+		return err
+	}
+	buf.Truncate(buf.Len() - 1) // Encode adds a newline
+	return nil
+}
+
+func encodeJSONNode(buf *bytes.Buffer, n *yaml.Node, indent string, depth int) error {
+	pad := func(d int) string { return strings.Repeat(indent, d) }
+
+	switch n.Kind {
+	case yaml.MappingNode, yaml.SequenceNode:
+		open, closing, step := "{", "}", 2
+		if n.Kind == yaml.SequenceNode {
+			open, closing, step = "[", "]", 1
+		}
+		if len(n.Content) == 0 {
+			buf.WriteString(open + closing)
+			return nil
+		}
+
+		buf.WriteString(open + "\n")
+		for i := 0; i < len(n.Content); i += step {
+			buf.WriteString(pad(depth + 1))
+			if step == 2 {
+				if err := encodeJSONString(buf, n.Content[i].Value); err != nil {
+					return err
+				}
+				buf.WriteString(": ")
+			}
+			if err := encodeJSONNode(buf, n.Content[i+step-1], indent, depth+1); err != nil {
+				return err
+			}
+			if i+step < len(n.Content) {
+				buf.WriteString(",")
+			}
+			buf.WriteString("\n")
+		}
+		buf.WriteString(pad(depth) + closing)
+	case yaml.ScalarNode:
+		if n.ShortTag() == "!!str" {
+			return encodeJSONString(buf, n.Value)
+		}
+		buf.WriteString(n.Value)
+	default:
+		return fmt.Errorf("Can't write %v as JSON", n.Kind)
+	}
+
+	return nil
+}
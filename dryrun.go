@@ -0,0 +1,30 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// dryRun is set by --dry-run. Requests that change anything in Smartling,
+// and writes to local files, are printed instead of being made.
+var dryRun bool
+
+func printDryRun(format string, a ...interface{}) {
+	fmt.Printf("Would "+format+"\n", a...)
+}
+
+// printDryRunRequest prints an API request that would be made
+func printDryRunRequest(method, endpoint string, params url.Values, body []byte, contentType string) {
+	u := endpoint
+	if len(params) > 0 {
+		u += "?" + params.Encode()
+	}
+	if len(body) > 0 && strings.HasPrefix(contentType, contentTypeJSON) {
+		printDryRun("%s %s %s", method, u, body)
+	} else if len(body) > 0 {
+		printDryRun("%s %s (%d bytes of %s)", method, u, len(body), contentType)
+	} else {
+		printDryRun("%s %s", method, u)
+	}
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDryRunStillMakesReadOnlyRequests(t *testing.T) {
+	f := newFakeSmartling(t)
+	f.use(t, &Config{})
+	dryRun = true
+	search := "/glossary-api/v3/accounts/account/glossaries/search"
+	f.handle("POST", search, func(r fakeRequest) interface{} { return nil })
+	create := "/jobs-api/v3/projects/project/jobs"
+	f.handle("POST", create, func(r fakeRequest) interface{} { return nil })
+
+	out := captureStdout(t, func() {
+		if err := client.requestJSON("POST", search, nil, map[string]interface{}{}, nil); err != nil {
+			t.Fatal(err)
+		}
+		if err := client.requestJSON("POST", create, nil, map[string]interface{}{}, nil); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	if len(f.Requests("POST", search)) != 1 {
+		t.Error("expected the search to be made")
+	}
+	if len(f.Requests("POST", create)) != 0 {
+		t.Error("expected the job not to be created")
+	}
+	if !strings.Contains(out, "Would POST "+create) {
+		t.Errorf("expected the job creation to be printed, got %q", out)
+	}
+}
+
+// dryRunProject sets up a project with en.json and a de-DE override, and
+// the given remote files
+func dryRunProject(t *testing.T, remoteFiles ...string) *fakeSmartling {
+	dir := t.TempDir()
+	chdir(t, dir)
+	writeTestFile(t, "en.json", `{"a": "A", "b": "B"}`)
+	writeTestFile(t, "overrides/de-DE.json", `{"b": "B override"}`)
+
+	f := newFakeProject(t, &Config{path: ".", FileGlobs: []string{"en.json"}, PullFilePath: "{{.Locale}}.json", Overrides: "overrides"}, "/en.json")
+	f.handle("GET", "/files-api/v2/projects/project/locales/de-DE/file", func(r fakeRequest) interface{} {
+		return []byte(`{"a": "A de", "b": "B de"}`)
+	})
+	dryRun = true
+
+	return f
+}
+
+func writeTestFile(t *testing.T, name, content string) {
+	if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(name, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDryRunPullRunsThePipeline(t *testing.T) {
+	dryRunProject(t, "/en.json")
+
+	out := captureStdout(t, func() { pullProjectFile("en.json", "de-DE", "") })
+	if !strings.Contains(out, "Would write de-DE.json") {
+		t.Errorf("expected the write to be printed, got %q", out)
+	}
+	if _, err := os.Stat("de-DE.json"); !os.IsNotExist(err) {
+		t.Error("expected de-DE.json not to be written")
+	}
+
+	// with the override applied the existing file is up to date
+	writeTestFile(t, "de-DE.json", "{\n  \"a\": \"A de\",\n  \"b\": \"B override\"\n}\n")
+	out = captureStdout(t, func() { pullProjectFile("en.json", "de-DE", "") })
+	if !strings.Contains(out, "Unchanged de-DE.json") {
+		t.Errorf("expected the override to be applied in a dry run, got %q", out)
+	}
+}
+
+func TestDryRunOverridesStaleSkipsUnpushedFiles(t *testing.T) {
+	f := dryRunProject(t)
+
+	out := runCommand(t, projectOverridesStaleCommand, "--prefix", "/test")
+	if strings.Contains(out, "B override") {
+		t.Errorf("expected no stale overrides, got %q", out)
+	}
+	if len(f.Requests("POST", "/files-api/v2/projects/project/file")) != 0 {
+		t.Error("expected nothing to be uploaded")
+	}
+}
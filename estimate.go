@@ -0,0 +1,107 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+
+	"github.com/urfave/cli"
+)
+
+// LocaleEstimate is the word counts and translation cost for a locale
+type LocaleEstimate struct {
+	Project           string   `json:"project,omitempty"`
+	Locale            string   `json:"locale"`
+	UntranslatedWords int      `json:"untranslated_words"`
+	AuthorizedWords   int      `json:"authorized_words"`
+	CompletedWords    int      `json:"completed_words"`
+	Rate              *float64 `json:"rate,omitempty"`
+	Cost              *float64 `json:"cost,omitempty"`
+}
+
+var projectEstimateCommand = cli.Command{
+	Name:  "estimate",
+	Usage: "estimate the cost of translating the project's remote files",
+	Flags: []cli.Flag{
+		prefixFlag,
+		cli.BoolFlag{
+			Name:  "json",
+			Usage: "Output the estimate as JSON",
+		},
+	},
+	Action: func(c *cli.Context) {
+		if len(c.Args()) > 0 {
+			log.Println("Wrong number of arguments")
+			log.Fatalln("Usage: estimate")
+		}
+
+		all := []LocaleEstimate{}
+		forEachProject(func() {
+			prefix := prefixOrGitPrefix(c.String("prefix"))
+			locales := fetchLocales()
+			statuses := GetProjectStatus(prefix, locales)
+
+			estimates := []LocaleEstimate{}
+			for _, locale := range locales {
+				estimates = append(estimates, estimateLocale(statuses, locale))
+			}
+			all = append(all, estimates...)
+
+			if !c.Bool("json") {
+				PrintEstimateTable(estimates)
+			}
+		})
+
+		if c.Bool("json") {
+			b, err := json.MarshalIndent(all, "", "  ")
+			logAndQuitIfError(err)
+			fmt.Println(string(b))
+		}
+	},
+}
+
+// estimateLocale prices the words that still need translating, i.e. those
+// untranslated or authorized but not yet completed
+func estimateLocale(ps *ProjectStatus, locale string) LocaleEstimate {
+	ls := ps.LocaleStatus(locale)
+	e := LocaleEstimate{
+		Project:           ProjectConfig.name,
+		Locale:            locale,
+		UntranslatedWords: ls.AwaitingAuthorizationWords(),
+		AuthorizedWords:   ls.AuthorizedWords,
+		CompletedWords:    ls.CompletedWords,
+	}
+
+	// without a rate the cost is unknown, so both are left out
+	if rate, ok := ProjectConfig.rate(locale); ok {
+		cost := float64(e.UntranslatedWords+e.AuthorizedWords) * rate
+		e.Rate, e.Cost = &rate, &cost
+	}
+
+	return e
+}
+
+func PrintEstimateTable(estimates []LocaleEstimate) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+
+	fmt.Fprint(w, "Locale\tUntranslated\tAuthorized\tCompleted\tRate\tCost\t\n")
+	total := LocaleEstimate{}
+	totalCost := 0.0
+	for _, e := range estimates {
+		rate, cost := "-", "-"
+		if e.Rate != nil {
+			rate = fmt.Sprintf("%.3f", *e.Rate)
+			cost = fmt.Sprintf("%.2f", *e.Cost)
+			totalCost += *e.Cost
+		}
+		fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%s\t%s\t\n", e.Locale, e.UntranslatedWords, e.AuthorizedWords, e.CompletedWords, rate, cost)
+
+		total.UntranslatedWords += e.UntranslatedWords
+		total.AuthorizedWords += e.AuthorizedWords
+		total.CompletedWords += e.CompletedWords
+	}
+	fmt.Fprintf(w, "Total\t%d\t%d\t%d\t\t%.2f\t\n", total.UntranslatedWords, total.AuthorizedWords, total.CompletedWords, totalCost)
+	w.Flush()
+}
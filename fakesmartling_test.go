@@ -0,0 +1,318 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/99designs/api-sdk-go"
+	"github.com/urfave/cli"
+)
+
+// fakeRequest is a request received by fakeSmartling
+type fakeRequest struct {
+	Method      string
+	Path        string
+	Query       url.Values
+	ContentType string
+	Body        []byte
+}
+
+// Form parses a form encoded body
+func (r fakeRequest) Form() url.Values {
+	v, _ := url.ParseQuery(string(r.Body))
+	return v
+}
+
+// fakeHandler returns the data of the response envelope for a request, or
+// []byte for a file download
+type fakeHandler func(r fakeRequest) interface{}
+
+// fakeSmartling stands in for the Smartling API so commands can be run
+// offline. It records each request and replies with the data returned by the
+// handler for the method and path, or a 404.
+type fakeSmartling struct {
+	*httptest.Server
+	mu       sync.Mutex
+	requests []fakeRequest
+	handlers map[string]fakeHandler
+}
+
+func newFakeSmartling(t *testing.T) *fakeSmartling {
+	f := &fakeSmartling{handlers: map[string]fakeHandler{}}
+	f.Server = httptest.NewServer(http.HandlerFunc(f.serve))
+	t.Cleanup(f.Close)
+
+	return f
+}
+
+func (f *fakeSmartling) handle(method, path string, h fakeHandler) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.handlers[method+" "+path] = h
+}
+
+func (f *fakeSmartling) serve(w http.ResponseWriter, r *http.Request) {
+	b, _ := ioutil.ReadAll(r.Body)
+	req := fakeRequest{
+		Method:      r.Method,
+		Path:        r.URL.Path,
+		Query:       r.URL.Query(),
+		ContentType: r.Header.Get("Content-Type"),
+		Body:        b,
+	}
+
+	f.mu.Lock()
+	f.requests = append(f.requests, req)
+	h, ok := f.handlers[r.Method+" "+r.URL.Path]
+	f.mu.Unlock()
+
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	data := h(req)
+	if b, ok := data.([]byte); ok {
+		_, _ = w.Write(b)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentTypeJSON)
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"response": map[string]interface{}{
+			"code": "SUCCESS",
+			"data": data,
+		},
+	})
+}
+
+// Requests returns the requests received for a method and path
+func (f *fakeSmartling) Requests(method, path string) []fakeRequest {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	rr := []fakeRequest{}
+	for _, r := range f.requests {
+		if r.Method == method && r.Path == path {
+			rr = append(rr, r)
+		}
+	}
+	return rr
+}
+
+// use points the global client and config at the fake server for the test
+func (f *fakeSmartling) use(t *testing.T, pc *Config) {
+	sc := smartling.NewClient("user", "secret")
+	sc.BaseURL = f.URL
+	sc.HTTP = f.Client()
+	sc.Credentials.AccessToken = &smartling.Token{Value: "token", ExpirationTime: time.Now().Add(time.Hour)}
+
+	oldClient, oldConfig, oldCachePath := client, ProjectConfig, cachePath
+	client = &FaultTolerantClient{sc, "project", 0}
+	ProjectConfig = pc
+	cachePath = t.TempDir()
+	resetRemoteFileList()
+	accountUID, fallbackSourceLocale.locale = "", ""
+	loadedOverrides.overrides = map[string]map[string]string{}
+	translatedStrings.files = nil
+	t.Cleanup(func() {
+		client, ProjectConfig, cachePath = oldClient, oldConfig, oldCachePath
+		dryRun = false
+	})
+}
+
+// newFakeProject points the client at a fake server for a project with the
+// given config, whose remote file list has the given files
+func newFakeProject(t *testing.T, pc *Config, remoteFiles ...string) *fakeSmartling {
+	f := newFakeSmartling(t)
+	f.use(t, pc)
+	f.handle("GET", "/files-api/v2/projects/project/files/list", func(r fakeRequest) interface{} {
+		items := []map[string]string{}
+		for _, uri := range remoteFiles {
+			items = append(items, map[string]string{"fileUri": uri})
+		}
+		return map[string]interface{}{"totalCount": len(items), "items": items}
+	})
+
+	return f
+}
+
+// fakeJobs is the state of the Jobs API on a fakeSmartling
+type fakeJobs struct {
+	f     *fakeSmartling
+	mu    sync.Mutex
+	jobs  []*Job
+	files map[string][]string
+}
+
+// handleJobs adds the Jobs API endpoints to the fake server
+func (f *fakeSmartling) handleJobs() *fakeJobs {
+	fj := &fakeJobs{f: f, files: map[string][]string{}}
+
+	f.handle("GET", fakeJobsEndpoint(""), func(r fakeRequest) interface{} {
+		fj.mu.Lock()
+		defer fj.mu.Unlock()
+
+		items := []Job{}
+		for _, j := range fj.jobs {
+			if name := r.Query.Get("jobName"); name == "" || j.JobName == name {
+				items = append(items, *j)
+			}
+		}
+		return jobsList{TotalCount: len(items), Items: items[fakeOffset(r, len(items)):]}
+	})
+
+	f.handle("POST", fakeJobsEndpoint(""), func(r fakeRequest) interface{} {
+		var req struct {
+			JobName         string
+			Description     string
+			DueDate         *smartling.UTC
+			TargetLocaleIDs []string `json:"targetLocaleIds"`
+		}
+		_ = json.Unmarshal(r.Body, &req)
+
+		return *fj.add(Job{
+			JobName:         req.JobName,
+			JobStatus:       "AWAITING_AUTHORIZATION",
+			Description:     req.Description,
+			DueDate:         req.DueDate,
+			TargetLocaleIDs: req.TargetLocaleIDs,
+		})
+	})
+
+	return fj
+}
+
+func fakeJobsEndpoint(path string) string {
+	return "/jobs-api/v3/projects/project/jobs" + path
+}
+
+func fakeOffset(r fakeRequest, max int) int {
+	var offset int
+	_, _ = fmt.Sscan(r.Query.Get("offset"), &offset)
+	if offset > max {
+		return max
+	}
+	return offset
+}
+
+// add creates a job and the endpoints for it
+func (fj *fakeJobs) add(j Job) *Job {
+	fj.mu.Lock()
+	j.TranslationJobUID = fmt.Sprintf("job%d", len(fj.jobs)+1)
+	job := &j
+	fj.jobs = append(fj.jobs, job)
+	fj.mu.Unlock()
+
+	uid := job.TranslationJobUID
+	fj.f.handle("GET", fakeJobsEndpoint("/"+uid), func(r fakeRequest) interface{} {
+		fj.mu.Lock()
+		defer fj.mu.Unlock()
+		return *job
+	})
+	fj.f.handle("POST", fakeJobsEndpoint("/"+uid+"/file/add"), func(r fakeRequest) interface{} {
+		var req struct{ FileURI string }
+		_ = json.Unmarshal(r.Body, &req)
+
+		fj.mu.Lock()
+		defer fj.mu.Unlock()
+		fj.files[uid] = append(fj.files[uid], req.FileURI)
+		return map[string]interface{}{"success": true}
+	})
+	fj.f.handle("POST", fakeJobsEndpoint("/"+uid+"/authorize"), func(r fakeRequest) interface{} {
+		fj.mu.Lock()
+		defer fj.mu.Unlock()
+		job.JobStatus = "IN_PROGRESS"
+		return nil
+	})
+	fj.f.handle("POST", fakeJobsEndpoint("/"+uid+"/cancel"), func(r fakeRequest) interface{} {
+		fj.mu.Lock()
+		defer fj.mu.Unlock()
+		job.JobStatus = "CANCELLED"
+		return nil
+	})
+
+	return job
+}
+
+// Files returns the files added to a job
+func (fj *fakeJobs) Files(uid string) []string {
+	fj.mu.Lock()
+	defer fj.mu.Unlock()
+
+	ff := append([]string{}, fj.files[uid]...)
+	sort.Strings(ff)
+	return ff
+}
+
+// runCommand runs a command as the CLI would, without its Before hook as the
+// client is already set up, and returns what it printed
+func runCommand(t *testing.T, cmd cli.Command, args ...string) string {
+	cmd.Before = nil
+	app := cli.NewApp()
+	app.Commands = []cli.Command{cmd}
+
+	return captureStdout(t, func() {
+		if err := app.Run(append([]string{"smartling", cmd.Name}, args...)); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
+
+// captureStdout returns what f prints
+func captureStdout(t *testing.T, f func()) string {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	out := make(chan string)
+	go func() {
+		b, _ := ioutil.ReadAll(r)
+		out <- string(b)
+	}()
+
+	f()
+	w.Close()
+
+	return <-out
+}
+
+// captureLog returns what f logs
+func captureLog(t *testing.T, f func()) string {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+
+	f()
+
+	return buf.String()
+}
+
+// chdir changes to dir for the rest of the test
+func chdir(t *testing.T, dir string) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = os.Chdir(wd)
+	})
+}
@@ -0,0 +1,244 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"strings"
+	"sync"
+)
+
+// Fallbacks fill the strings that aren't translated in a locale from other
+// locales, e.g. fr-CA: [fr-FR, en] fills them from fr-FR, then from the
+// source if en is the source locale.
+
+var fallbackSourceLocale struct {
+	sync.Mutex
+	locale string
+}
+
+func cachedSourceLocale() string {
+	fallbackSourceLocale.Lock()
+	defer fallbackSourceLocale.Unlock()
+
+	if fallbackSourceLocale.locale == "" {
+		fallbackSourceLocale.locale = sourceLocale()
+	}
+	return fallbackSourceLocale.locale
+}
+
+// isSourceFallback reports whether a fallback is the source locale, or its
+// language, e.g. en when the source is en-US
+func isSourceFallback(fallback string) bool {
+	source := cachedSourceLocale()
+	return fallback == source || (localeRegion(fallback) == "" && fallback == localeLanguage(source))
+}
+
+// translatedStrings caches the strings that are translated in each file and
+// locale, so each fallback is only downloaded once however many locales use it
+var translatedStrings struct {
+	sync.Mutex
+	files map[string]*translatedFile
+}
+
+type translatedFile struct {
+	sync.Once
+	strings map[string]string
+	err     error
+}
+
+// fetchTranslatedStrings returns the strings of a file that are translated in
+// a locale, as Smartling reports them rather than guessing from the text
+func fetchTranslatedStrings(projectFilepath, locale, prefix string) (map[string]string, error) {
+	cacheFile := cacheFilePath(projectFilepath, locale) + ".translated"
+
+	translatedStrings.Lock()
+	if translatedStrings.files == nil {
+		translatedStrings.files = map[string]*translatedFile{}
+	}
+	tf, ok := translatedStrings.files[cacheFile]
+	if !ok {
+		tf = &translatedFile{}
+		translatedStrings.files[cacheFile] = tf
+	}
+	translatedStrings.Unlock()
+
+	tf.Do(func() {
+		tf.strings, tf.err = downloadTranslatedStrings(projectFilepath, locale, prefix, cacheFile)
+	})
+
+	return tf.strings, tf.err
+}
+
+func downloadTranslatedStrings(projectFilepath, locale, prefix, cacheFile string) (map[string]string, error) {
+	hit, b := getCachedTranslations(cacheFile)
+	if !hit {
+		remotePath := findIdenticalRemoteFileOrPush(projectFilepath, prefix)
+		if dryRun && !getRemoteFileList().contains(remotePath) {
+			return nil, errNotPushed
+		}
+
+		var err error
+		b, err = client.DownloadTranslatedStrings(locale, remotePath)
+		if err != nil {
+			return nil, err
+		}
+		if !dryRun {
+			if err := ioutil.WriteFile(cacheFile, b, 0644); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	translations, err := parseTranslations(b, filetypeForProjectFile(projectFilepath))
+	if err != nil {
+		return nil, err
+	}
+	for key, v := range translations {
+		if v == "" {
+			delete(translations, key)
+		}
+	}
+
+	return translations, nil
+}
+
+// prefetchFallbacks downloads the fallbacks of the locales being pulled
+// before the files are pulled concurrently
+func prefetchFallbacks(locales []string, prefix string) {
+	for _, projectFilepath := range ProjectConfig.Files() {
+		if !isParseableFileType(filetypeForProjectFile(projectFilepath)) {
+			continue
+		}
+		for _, locale := range locales {
+			for _, fallback := range ProjectConfig.Fallbacks[locale] {
+				if isSourceFallback(fallback) {
+					continue
+				}
+				_, err := fetchTranslatedStrings(projectFilepath, fallback, prefix)
+				if err != errNotPushed {
+					logAndQuitIfError(err)
+				}
+			}
+		}
+	}
+}
+
+// topLevelKey returns the first part of the dotted keys if they all share it,
+// e.g. the locale in files like Rails'
+func topLevelKey(keys []string) string {
+	top := ""
+	for i, key := range keys {
+		j := strings.Index(key, ".")
+		if j == -1 || (i > 0 && key[:j] != top) {
+			return ""
+		}
+		top = key[:j]
+	}
+	return top
+}
+
+// remapTopLevelKey changes the top level key of a dotted key from one file's
+// to another's, e.g. en.title to fr-CA.title
+func remapTopLevelKey(key, from, to string) string {
+	if from == "" || to == "" || !strings.HasPrefix(key, from+".") {
+		return key
+	}
+	return to + strings.TrimPrefix(key, from)
+}
+
+// applyFallbacks fills the keys of a pulled file that aren't translated from
+// the locale's fallbacks, in order. Keys that are still missing are filled
+// from the source so the file is complete.
+func applyFallbacks(projectFilepath, locale, prefix string, b []byte) []byte {
+	fallbacks := ProjectConfig.Fallbacks[locale]
+	ft := filetypeForProjectFile(projectFilepath)
+	if len(fallbacks) == 0 || !isParseableFileType(ft) {
+		return b
+	}
+
+	source, err := parseTranslationFile(readFile(projectFilepath), ft)
+	logAndQuitIfError(err)
+	tf, err := parseTranslationFile(b, ft)
+	logAndQuitIfError(err)
+	translated, err := fetchTranslatedStrings(projectFilepath, locale, prefix)
+	logAndQuitIfError(err)
+
+	// files like Rails' have the locale as the top level key, so the keys
+	// of the source are mapped to those of the translations
+	sourceTop, targetTop := topLevelKey(source.Keys()), topLevelKey(tf.Keys())
+	targetKey := func(key string) string {
+		return remapTopLevelKey(key, sourceTop, targetTop)
+	}
+
+	sourceStrings := source.Translations()
+	missing := []string{}
+	for _, key := range source.Keys() {
+		if _, ok := translated[targetKey(key)]; !ok {
+			missing = append(missing, key)
+		}
+	}
+	if len(missing) == 0 {
+		return b
+	}
+
+	// untranslated strings are downloaded as the source, but keys missing
+	// altogether are only added where their parent exists
+	fillFromSource := func(keys []string) (stillMissing []string) {
+		for _, key := range keys {
+			tk := targetKey(key)
+			if _, ok := tf.Get(tk); ok || tf.hasParent(tk) {
+				tf.Set(tk, sourceStrings[key])
+			} else {
+				stillMissing = append(stillMissing, key)
+			}
+		}
+		return stillMissing
+	}
+
+	filled := map[string]int{}
+	for _, fallback := range fallbacks {
+		if len(missing) == 0 {
+			break
+		}
+
+		if isSourceFallback(fallback) {
+			stillMissing := fillFromSource(missing)
+			filled[fallback] += len(missing) - len(stillMissing)
+			missing = stillMissing
+			break
+		}
+
+		fallbackStrings, err := fetchTranslatedStrings(projectFilepath, fallback, prefix)
+		logAndQuitIfError(err)
+
+		fallbackTop := topLevelKey(sortedKeys(fallbackStrings))
+		stillMissing := []string{}
+		for _, key := range missing {
+			if v, ok := fallbackStrings[remapTopLevelKey(key, sourceTop, fallbackTop)]; ok {
+				tf.Set(targetKey(key), v)
+				filled[fallback]++
+			} else {
+				stillMissing = append(stillMissing, key)
+			}
+		}
+		missing = stillMissing
+	}
+	fillFromSource(missing)
+
+	report := []string{}
+	for _, fallback := range fallbacks {
+		if filled[fallback] > 0 {
+			report = append(report, fmt.Sprintf("%d from %s", filled[fallback], fallback))
+		}
+	}
+	if len(missing) > 0 {
+		report = append(report, fmt.Sprintf("%d untranslated", len(missing)))
+	}
+	log.Printf("Filled %s %s: %s\n", localRelativeFilePath(projectFilepath), locale, strings.Join(report, ", "))
+
+	b, err = tf.Bytes()
+	logAndQuitIfError(err)
+
+	return b
+}
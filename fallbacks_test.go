@@ -0,0 +1,129 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// fallbacksProject sets up a pushed file, and the downloads of its
+// translations with and without the source for untranslated strings
+func fallbacksProject(t *testing.T, file, source string, downloads map[string][2]string) *fakeSmartling {
+	chdir(t, t.TempDir())
+	writeTestFile(t, file, source)
+
+	f := newFakeProject(t, &Config{path: ".", FileGlobs: []string{file}, Fallbacks: map[string][]string{
+		"fr-CA": {"fr-FR", "en"},
+		"fr-BE": {"fr-FR", "en"},
+	}}, "/"+file)
+	fallbackSourceLocale.locale = "en-US"
+	for locale, d := range downloads {
+		d := d
+		f.handle("GET", "/files-api/v2/projects/project/locales/"+locale+"/file", func(r fakeRequest) interface{} {
+			if r.Query.Get("includeOriginalStrings") == "false" {
+				return []byte(d[1])
+			}
+			return []byte(d[0])
+		})
+	}
+
+	return f
+}
+
+func TestApplyFallbacks(t *testing.T) {
+	f := fallbacksProject(t, "en.json", `{"a": "A", "b": "B", "c": "C", "d": "Same"}`, map[string][2]string{
+		"fr-CA": {`{"a": "A ca", "b": "B", "c": "C", "d": "Same"}`, `{"a": "A ca", "b": "", "c": "", "d": "Same"}`},
+		"fr-BE": {`{"a": "A", "b": "B", "c": "C", "d": "Same"}`, `{"a": "", "b": "", "c": "", "d": ""}`},
+		"fr-FR": {`{"a": "A fr", "b": "B fr", "c": "C", "d": "Same"}`, `{"a": "A fr", "b": "B fr", "c": "", "d": ""}`},
+	})
+
+	prefetchFallbacks([]string{"fr-CA", "fr-BE"}, "")
+	var b []byte
+	captureStdout(t, func() {
+		b = applyFallbacks("en.json", "fr-CA", "", []byte(`{"a": "A ca", "b": "B", "c": "C", "d": "Same"}`))
+		applyFallbacks("en.json", "fr-BE", "", []byte(`{"a": "A", "b": "B", "c": "C", "d": "Same"}`))
+	})
+
+	// d is translated the same as the source, so isn't filled from fr-FR
+	want := `{"a": "A ca", "b": "B fr", "c": "C", "d": "Same"}`
+	if compact(string(b)) != compact(want) {
+		t.Errorf("got %s, want %s", b, want)
+	}
+	if n := len(f.Requests("GET", "/files-api/v2/projects/project/locales/fr-FR/file")); n != 1 {
+		t.Errorf("got %d fr-FR downloads, want 1", n)
+	}
+}
+
+func TestApplyFallbacksDoesntAddTheSourceLocaleTree(t *testing.T) {
+	fallbacksProject(t, "en.yml", "en:\n  a: A\n", map[string][2]string{
+		"fr-FR": {"fr:\n  a: A\n", "fr:\n  a: \"\"\n"},
+		"fr-CA": {"fr:\n  a: A\n", "fr:\n  a: \"\"\n"},
+	})
+
+	var b []byte
+	captureStdout(t, func() {
+		b = applyFallbacks("en.yml", "fr-CA", "", []byte("fr:\n  a: A\n"))
+	})
+
+	if strings.Contains(string(b), "en:") {
+		t.Errorf("expected no en tree, got:\n%s", b)
+	}
+}
+
+func TestIsSourceFallback(t *testing.T) {
+	fallbackSourceLocale.locale = "en-US"
+	defer func() { fallbackSourceLocale.locale = "" }()
+
+	for fallback, want := range map[string]bool{"en-US": true, "en": true, "en-GB": false, "fr": false} {
+		if got := isSourceFallback(fallback); got != want {
+			t.Errorf("isSourceFallback(%q) = %v, want %v", fallback, got, want)
+		}
+	}
+}
+
+func compact(s string) string {
+	return strings.Join(strings.Fields(s), "")
+}
+
+func TestApplyFallbacksFillsRailsStyleFiles(t *testing.T) {
+	fallbacksProject(t, "en.yml", "en:\n  title: Title\n  save: Save\n  cancel: Cancel\n", map[string][2]string{
+		"fr-CA": {"fr-CA:\n  title: Titre CA\n  save: Save\n  cancel: Cancel\n", "fr-CA:\n  title: Titre CA\n  save: \"\"\n  cancel: \"\"\n"},
+		"fr-FR": {"fr-FR:\n  title: Titre\n  save: Enregistrer\n  cancel: Cancel\n", "fr-FR:\n  title: Titre\n  save: Enregistrer\n  cancel: \"\"\n"},
+	})
+
+	var b []byte
+	out := captureLog(t, func() {
+		b = applyFallbacks("en.yml", "fr-CA", "", []byte("fr-CA:\n  title: Titre CA\n  save: Save\n  cancel: Cancel\n"))
+	})
+
+	want := "fr-CA:\n  title: Titre CA\n  save: Enregistrer\n  cancel: Cancel\n"
+	if string(b) != want {
+		t.Errorf("got:\n%s\nwant:\n%s", b, want)
+	}
+	if !strings.Contains(out, "1 from fr-FR, 1 from en") {
+		t.Errorf("expected a fill from each fallback to be logged, got %q", out)
+	}
+}
+
+func TestTopLevelKey(t *testing.T) {
+	tests := []struct {
+		keys []string
+		want string
+	}{
+		{[]string{"en.title", "en.nav.home"}, "en"},
+		{[]string{"title", "nav.home"}, ""},
+		{[]string{"en.title", "fr.title"}, ""},
+		{nil, ""},
+	}
+	for _, tt := range tests {
+		if got := topLevelKey(tt.keys); got != tt.want {
+			t.Errorf("topLevelKey(%v) = %q, want %q", tt.keys, got, tt.want)
+		}
+	}
+
+	if got := remapTopLevelKey("en.nav.home", "en", "fr-CA"); got != "fr-CA.nav.home" {
+		t.Errorf("got %q, want fr-CA.nav.home", got)
+	}
+	if got := remapTopLevelKey("english.title", "en", "fr-CA"); got != "english.title" {
+		t.Errorf("got %q, want english.title unchanged", got)
+	}
+}
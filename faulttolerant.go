@@ -2,9 +2,11 @@ package main
 
 import (
 	"bytes"
+	"fmt"
 	"io"
 	"log"
 	"net"
+	"net/url"
 	"strings"
 	"time"
 
@@ -69,7 +71,23 @@ func (c *FaultTolerantClient) execWithRetry(f func() error) {
 	}
 }
 
+func (c *FaultTolerantClient) filesEndpoint(path string) string {
+	return fmt.Sprintf("/files-api/v2/projects/%s%s", c.ProjectID, path)
+}
+
 func (c *FaultTolerantClient) Upload(req *smartling.FileUploadRequest) (r *smartling.FileUploadResult, err error) {
+	if dryRun {
+		params := url.Values{"fileUri": {req.FileURI}, "fileType": {string(req.FileType)}}
+		if req.Authorize {
+			params.Set("authorize", "true")
+		}
+		for k, v := range req.Smartling.Directives {
+			params.Set("smartling."+k, v)
+		}
+		printDryRunRequest("POST", c.filesEndpoint("/file"), params, req.File, "multipart/form-data")
+		return &smartling.FileUploadResult{}, nil
+	}
+
 	c.execWithRetry(func() error {
 		r, err = c.Client.UploadFile(c.ProjectID, *req)
 
@@ -98,6 +116,20 @@ func (c *FaultTolerantClient) DownloadTranslation(locale string, req smartling.F
 	return
 }
 
+// DownloadTranslatedStrings downloads the translation of a file with an
+// empty string for each string that isn't translated, rather than the source
+func (c *FaultTolerantClient) DownloadTranslatedStrings(locale, fileURI string) (b []byte, err error) {
+	params := url.Values{
+		"fileUri":                {fileURI},
+		"includeOriginalStrings": {"false"},
+	}
+	c.execWithRetry(func() error {
+		b, err = c.download(c.filesEndpoint("/locales/"+locale+"/file"), params)
+		return err
+	})
+	return
+}
+
 func (c *FaultTolerantClient) List(req smartling.FilesListRequest) (ff *smartling.FilesList, err error) {
 	c.execWithRetry(func() error {
 		ff, err = c.Client.ListFiles(c.ProjectID, req)
@@ -106,6 +138,14 @@ func (c *FaultTolerantClient) List(req smartling.FilesListRequest) (ff *smartlin
 	return
 }
 
+func (c *FaultTolerantClient) ListAll(req smartling.FilesListRequest) (ff []smartling.File, err error) {
+	c.execWithRetry(func() error {
+		ff, err = c.Client.ListAllFiles(c.ProjectID, req)
+		return err
+	})
+	return
+}
+
 func (c *FaultTolerantClient) Status(fileUri string) (f *smartling.FileStatus, err error) {
 	c.execWithRetry(func() error {
 		f, err = client.GetFileStatus(c.ProjectID, fileUri)
@@ -115,6 +155,11 @@ func (c *FaultTolerantClient) Status(fileUri string) (f *smartling.FileStatus, e
 }
 
 func (c *FaultTolerantClient) Rename(oldFileUri, newFileUri string) (err error) {
+	if dryRun {
+		printDryRunRequest("POST", c.filesEndpoint("/file/rename"), url.Values{"fileUri": {oldFileUri}, "newFileUri": {newFileUri}}, nil, "")
+		return nil
+	}
+
 	c.execWithRetry(func() error {
 		err = c.Client.RenameFile(c.ProjectID, oldFileUri, newFileUri)
 		return err
@@ -123,6 +168,11 @@ func (c *FaultTolerantClient) Rename(oldFileUri, newFileUri string) (err error)
 }
 
 func (c *FaultTolerantClient) Delete(fileUri string) (err error) {
+	if dryRun {
+		printDryRunRequest("POST", c.filesEndpoint("/file/delete"), url.Values{"fileUri": {fileUri}}, nil, "")
+		return nil
+	}
+
 	c.execWithRetry(func() error {
 		err = c.Client.DeleteFile(c.ProjectID, fileUri)
 		return err
@@ -139,6 +189,28 @@ func (c *FaultTolerantClient) LastModified(req smartling.FileLastModifiedRequest
 	return
 }
 
+func (c *FaultTolerantClient) Import(locale string, req smartling.ImportRequest) (r *smartling.FileImportResult, err error) {
+	if dryRun {
+		params := url.Values{"fileUri": {req.FileURI}, "fileType": {string(req.FileType)}, "translationState": {string(req.TranslationState)}}
+		printDryRunRequest("POST", c.filesEndpoint("/locales/"+locale+"/file/import"), params, req.File, "multipart/form-data")
+		return &smartling.FileImportResult{}, nil
+	}
+
+	c.execWithRetry(func() error {
+		r, err = c.Client.Import(c.ProjectID, locale, req)
+		return err
+	})
+	return
+}
+
+func (c *FaultTolerantClient) ProjectDetails() (pd *smartling.ProjectDetails, err error) {
+	c.execWithRetry(func() error {
+		pd, err = c.Client.GetProjectDetails(c.ProjectID)
+		return err
+	})
+	return
+}
+
 func (c *FaultTolerantClient) Locales() (tl []smartling.Locale, err error) {
 	c.execWithRetry(func() error {
 		var pd *smartling.ProjectDetails
@@ -154,3 +226,32 @@ func (c *FaultTolerantClient) Locales() (tl []smartling.Locale, err error) {
 	})
 	return
 }
+
+// Authorize authorizes a file for the locales. Smartling authorizes every
+// locale when none are given, so that's refused.
+func (c *FaultTolerantClient) Authorize(fileUri string, locales []string) (err error) {
+	if len(locales) == 0 {
+		return fmt.Errorf("No locales given to authorize %s for", fileUri)
+	}
+
+	body := url.Values{"fileUri": {fileUri}, "localeIdsToAuthorize[]": locales}
+	c.execWithRetry(func() error {
+		err = c.request("POST", c.filesEndpoint("/file/authorized-locales"), nil, []byte(body.Encode()), contentTypeForm, nil)
+		return err
+	})
+	return
+}
+
+// Unauthorize unauthorizes a file for translation into the given locales
+func (c *FaultTolerantClient) Unauthorize(fileUri string, locales []string) (err error) {
+	if len(locales) == 0 {
+		return fmt.Errorf("No locales given to unauthorize %s for", fileUri)
+	}
+
+	c.execWithRetry(func() error {
+		params := url.Values{"fileUri": {fileUri}, "localeIds[]": locales}
+		err = c.requestJSON("DELETE", c.filesEndpoint("/file/authorized-locales"), params, nil, nil)
+		return err
+	})
+	return
+}
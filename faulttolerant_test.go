@@ -0,0 +1,56 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAuthorizeSendsLocalesToAuthorize(t *testing.T) {
+	f := newFakeSmartling(t)
+	f.use(t, &Config{})
+	endpoint := "/files-api/v2/projects/project/file/authorized-locales"
+	f.handle("POST", endpoint, func(r fakeRequest) interface{} { return nil })
+
+	if err := client.Authorize("/file.json", []string{"de-DE", "fr-FR"}); err != nil {
+		t.Fatal(err)
+	}
+
+	rr := f.Requests("POST", endpoint)
+	if len(rr) != 1 {
+		t.Fatalf("got %d requests, want 1", len(rr))
+	}
+	if rr[0].ContentType != contentTypeForm {
+		t.Errorf("got content type %q, want %q", rr[0].ContentType, contentTypeForm)
+	}
+	form := rr[0].Form()
+	if got := form.Get("fileUri"); got != "/file.json" {
+		t.Errorf("got fileUri %q, want /file.json", got)
+	}
+	if got := form["localeIdsToAuthorize[]"]; !reflect.DeepEqual(got, []string{"de-DE", "fr-FR"}) {
+		t.Errorf("got localeIdsToAuthorize[] %v, want [de-DE fr-FR]", got)
+	}
+}
+
+func TestAuthorizeRefusesNoLocales(t *testing.T) {
+	f := newFakeSmartling(t)
+	f.use(t, &Config{})
+
+	if err := client.Authorize("/file.json", nil); err == nil {
+		t.Error("expected an error authorizing no locales")
+	}
+	if len(f.requests) != 0 {
+		t.Errorf("got %d requests, want none", len(f.requests))
+	}
+}
+
+func TestUnauthorizeRefusesNoLocales(t *testing.T) {
+	f := newFakeSmartling(t)
+	f.use(t, &Config{})
+
+	if err := client.Unauthorize("/file.json", nil); err == nil {
+		t.Error("expected an error unauthorizing no locales")
+	}
+	if len(f.requests) != 0 {
+		t.Errorf("got %d requests, want none", len(f.requests))
+	}
+}
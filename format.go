@@ -0,0 +1,200 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/99designs/api-sdk-go"
+	"gopkg.in/yaml.v3"
+)
+
+// FormatConfig makes the pulled files matching the globs look like their
+// source file, so pulls don't cause noisy diffs
+type FormatConfig struct {
+	FileGlobs []string `yaml:"files"`
+
+	// KeyOrder is "source" to order keys like the source file (the default),
+	// or "downloaded" to keep the order Smartling returns
+	KeyOrder string `yaml:"key_order,omitempty"`
+
+	// Indent is "source" to indent like the source file (the default), a
+	// number of spaces, or "tab"
+	Indent string `yaml:"indent,omitempty"`
+}
+
+func (fc FormatConfig) matches(projectFilepath string) bool {
+	for _, g := range fc.FileGlobs {
+		if ok, _ := filepath.Match(g, projectFilepath); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func formatConfig(projectFilepath string) (FormatConfig, bool) {
+	for _, fc := range ProjectConfig.Formats {
+		if fc.matches(projectFilepath) {
+			return fc, true
+		}
+	}
+	return FormatConfig{}, false
+}
+
+// formatPulledFile rewrites a pulled file to match the source file's key
+// order, indentation, quote style and trailing newline. If the existing
+// local file at fp has the same strings it's returned instead, so files
+// aren't rewritten when nothing has changed.
+func formatPulledFile(projectFilepath, fp string, b []byte) []byte {
+	fc, ok := formatConfig(projectFilepath)
+	ft := filetypeForProjectFile(projectFilepath)
+	if !ok || !isParseableFileType(ft) {
+		return b
+	}
+
+	src := readFile(projectFilepath)
+	source, err := parseTranslationFile(src, ft)
+	logAndQuitIfError(err)
+	tf, err := parseTranslationFile(b, ft)
+	logAndQuitIfError(err)
+
+	if fc.KeyOrder != "downloaded" {
+		orderLike(tf.root(), source.root(), true)
+	}
+	styleLike(tf.root(), source.root(), true)
+
+	switch fc.Indent {
+	case "", "source":
+		tf.indent = detectIndent(src)
+	case "tab":
+		tf.indent = "\t"
+	default:
+		n, err := strconv.Atoi(fc.Indent)
+		logAndQuitIfError(err)
+		tf.indent = strings.Repeat(" ", n)
+	}
+	if tf.fileType == smartling.FileTypeYAML && strings.Contains(tf.indent, "\t") {
+		// YAML can't be indented with tabs, so it's indented like the source
+		tf.indent = detectIndent(src)
+		if strings.Contains(tf.indent, "\t") {
+			tf.indent = "  "
+		}
+	}
+
+	b, err = tf.Bytes()
+	logAndQuitIfError(err)
+	if !bytes.HasSuffix(src, []byte("\n")) {
+		b = bytes.TrimRight(b, "\n")
+	}
+
+	if existing, err := ioutil.ReadFile(fp); err == nil {
+		if etf, err := parseTranslationFile(existing, ft); err == nil && reflect.DeepEqual(etf.Translations(), tf.Translations()) {
+			return existing
+		}
+	}
+
+	return b
+}
+
+// detectIndent returns the indentation of the first indented line, or a tab
+// if it's indented with tabs
+func detectIndent(b []byte) string {
+	for _, line := range strings.Split(string(b), "\n") {
+		trimmed := strings.TrimLeft(line, " \t")
+		if trimmed != "" && len(trimmed) < len(line) {
+			if line[0] == '\t' {
+				return "\t"
+			}
+			return line[:len(line)-len(trimmed)]
+		}
+	}
+	return "  "
+}
+
+// pairedNode returns the node in src for the key of a mapping in n. At the
+// top level a single key is paired with a single key, as files like Rails'
+// have the locale as the top level key.
+func pairedNode(src *yaml.Node, key string, top bool) *yaml.Node {
+	if src.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(src.Content); i += 2 {
+		if src.Content[i].Value == key {
+			return src.Content[i+1]
+		}
+	}
+	if top && len(src.Content) == 2 {
+		return src.Content[1]
+	}
+	return nil
+}
+
+// orderLike orders the keys of the mappings in n like those in src, with
+// keys that aren't in src last
+func orderLike(n, src *yaml.Node, top bool) {
+	switch n.Kind {
+	case yaml.MappingNode:
+		if src.Kind == yaml.MappingNode {
+			index := map[string]int{}
+			for i := 0; i+1 < len(src.Content); i += 2 {
+				index[src.Content[i].Value] = i
+			}
+			position := func(k string) int {
+				if i, ok := index[k]; ok {
+					return i
+				}
+				return len(src.Content)
+			}
+
+			pairs := [][2]*yaml.Node{}
+			for i := 0; i+1 < len(n.Content); i += 2 {
+				pairs = append(pairs, [2]*yaml.Node{n.Content[i], n.Content[i+1]})
+			}
+			sort.SliceStable(pairs, func(i, j int) bool {
+				return position(pairs[i][0].Value) < position(pairs[j][0].Value)
+			})
+			n.Content = n.Content[:0]
+			for _, p := range pairs {
+				n.Content = append(n.Content, p[0], p[1])
+			}
+		}
+
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			if s := pairedNode(src, n.Content[i].Value, top && len(n.Content) == 2); s != nil {
+				orderLike(n.Content[i+1], s, false)
+			}
+		}
+	case yaml.SequenceNode:
+		if src.Kind == yaml.SequenceNode {
+			for i := 0; i < len(n.Content) && i < len(src.Content); i++ {
+				orderLike(n.Content[i], src.Content[i], false)
+			}
+		}
+	}
+}
+
+// styleLike quotes the strings in n like the same strings in src
+func styleLike(n, src *yaml.Node, top bool) {
+	switch n.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			if s := pairedNode(src, n.Content[i].Value, top && len(n.Content) == 2); s != nil {
+				styleLike(n.Content[i+1], s, false)
+			}
+		}
+	case yaml.SequenceNode:
+		if src.Kind == yaml.SequenceNode {
+			for i := 0; i < len(n.Content) && i < len(src.Content); i++ {
+				styleLike(n.Content[i], src.Content[i], false)
+			}
+		}
+	case yaml.ScalarNode:
+		if src.Kind == yaml.ScalarNode && n.ShortTag() == "!!str" {
+			n.Style = src.Style
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func formatProject(t *testing.T, fc FormatConfig) {
+	chdir(t, t.TempDir())
+	pc := ProjectConfig
+	ProjectConfig = &Config{path: ".", Formats: []FormatConfig{fc}}
+	t.Cleanup(func() { ProjectConfig = pc })
+}
+
+func TestDetectIndent(t *testing.T) {
+	tests := []struct {
+		src, want string
+	}{
+		{"{\n  \"a\": \"A\"\n}\n", "  "},
+		{"{\n    \"a\": {\n        \"b\": \"B\"\n    }\n}\n", "    "},
+		{"{\n\t\"a\": \"A\"\n}\n", "\t"},
+		{"{\n\t\"a\": {\n\t\t\"b\": \"B\"\n\t}\n}\n", "\t"},
+		{"\n\nen:\n    a: A\n", "    "},
+		{`{"a": "A"}`, "  "},
+	}
+
+	for _, tt := range tests {
+		if got := detectIndent([]byte(tt.src)); got != tt.want {
+			t.Errorf("detectIndent(%q) = %q, want %q", tt.src, got, tt.want)
+		}
+	}
+}
+
+func TestOrderLike(t *testing.T) {
+	n := parseYAMLNode(t, "c: C\nnew: N\na:\n  y: Y\n  x: X\nb: B\n")
+	src := parseYAMLNode(t, "a:\n  x: X\n  y: Y\nb: B\nc: C\n")
+
+	orderLike(n, src, true)
+
+	if got := nodeKeys(n); got != "a b c new" {
+		t.Errorf("got %s, want the source order with new keys last", got)
+	}
+	if got := nodeKeys(n.Content[1]); got != "x y" {
+		t.Errorf("got nested %s, want x y", got)
+	}
+}
+
+func TestOrderLikeRailsStyle(t *testing.T) {
+	n := parseYAMLNode(t, "de:\n  b: B\n  a: A\n")
+	src := parseYAMLNode(t, "en:\n  a: A\n  b: B\n")
+
+	orderLike(n, src, true)
+
+	if got := nodeKeys(n.Content[1]); got != "a b" {
+		t.Errorf("got %s, want the keys under the locale ordered like the source", got)
+	}
+}
+
+func TestStyleLike(t *testing.T) {
+	n := parseYAMLNode(t, "a: A\nb: B\nn: 1\n")
+	src := parseYAMLNode(t, "a: 'A'\nb: \"B\"\nn: '1'\n")
+
+	styleLike(n, src, true)
+
+	want := map[string]yaml.Style{"a": yaml.SingleQuotedStyle, "b": yaml.DoubleQuotedStyle, "n": 0}
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		if got := n.Content[i+1].Style; got != want[n.Content[i].Value] {
+			t.Errorf("%s has style %v, want %v", n.Content[i].Value, got, want[n.Content[i].Value])
+		}
+	}
+}
+
+func TestFormatPulledFile(t *testing.T) {
+	tests := []struct {
+		name         string
+		fc           FormatConfig
+		source, want string
+	}{
+		{
+			"key order and indent",
+			FormatConfig{},
+			"{\n    \"b\": \"B\",\n    \"a\": \"A\"\n}\n",
+			"{\n    \"b\": \"B de\",\n    \"a\": \"A de\"\n}\n",
+		},
+		{
+			"tab indent",
+			FormatConfig{},
+			"{\n\t\"b\": \"B\",\n\t\"a\": \"A\"\n}\n",
+			"{\n\t\"b\": \"B de\",\n\t\"a\": \"A de\"\n}\n",
+		},
+		{
+			"downloaded order",
+			FormatConfig{KeyOrder: "downloaded", Indent: "3"},
+			"{\n  \"b\": \"B\",\n  \"a\": \"A\"\n}\n",
+			"{\n   \"a\": \"A de\",\n   \"b\": \"B de\"\n}\n",
+		},
+		{
+			"no trailing newline",
+			FormatConfig{Indent: "tab"},
+			"{\n  \"b\": \"B\",\n  \"a\": \"A\"\n}",
+			"{\n\t\"b\": \"B de\",\n\t\"a\": \"A de\"\n}",
+		},
+	}
+
+	for _, tt := range tests {
+		tt.fc.FileGlobs = []string{"en.json"}
+		formatProject(t, tt.fc)
+		writeTestFile(t, "en.json", tt.source)
+
+		got := string(formatPulledFile("en.json", "de.json", []byte(`{"a": "A de", "b": "B de"}`)))
+		if got != tt.want {
+			t.Errorf("%s: got:\n%s\nwant:\n%s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestFormatPulledYAMLFile(t *testing.T) {
+	formatProject(t, FormatConfig{FileGlobs: []string{"en.yml"}, Indent: "tab"})
+	writeTestFile(t, "en.yml", "en:\n    b: 'B'\n    a: \"A\"\n")
+
+	got := string(formatPulledFile("en.yml", "de.yml", []byte("de:\n  a: A de\n  b: B de\n")))
+	want := "de:\n    b: 'B de'\n    a: \"A de\"\n"
+	if got != want {
+		t.Errorf("got:\n%s\nwant the quotes and indent of the source:\n%s", got, want)
+	}
+}
+
+func TestFormatPulledFileKeepsUnchangedFiles(t *testing.T) {
+	formatProject(t, FormatConfig{FileGlobs: []string{"en.json"}})
+	writeTestFile(t, "en.json", "{\n  \"a\": \"A\"\n}\n")
+	existing := `{"a":"A de"}`
+	writeTestFile(t, "de.json", existing)
+
+	if got := string(formatPulledFile("en.json", "de.json", []byte(`{"a": "A de"}`))); got != existing {
+		t.Errorf("got %q, want the existing file %q", got, existing)
+	}
+}
+
+func parseYAMLNode(t *testing.T, s string) *yaml.Node {
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(s), &doc); err != nil {
+		t.Fatal(err)
+	}
+	return doc.Content[0]
+}
+
+func nodeKeys(n *yaml.Node) string {
+	keys := []string{}
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		keys = append(keys, n.Content[i].Value)
+	}
+	return strings.Join(keys, " ")
+}
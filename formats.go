@@ -0,0 +1,75 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/99designs/api-sdk-go"
+	"gopkg.in/yaml.v2"
+)
+
+// isParseableFileType reports whether translation files of this type can be
+// parsed into key/value pairs
+func isParseableFileType(ft smartling.FileType) bool {
+	return ft == smartling.FileTypeJSON || ft == smartling.FileTypeYAML
+}
+
+// parseTranslations flattens a JSON or YAML translation file into a map of
+// dotted keys to string values. Non-string values are ignored.
+func parseTranslations(b []byte, ft smartling.FileType) (map[string]string, error) {
+	var v interface{}
+	var err error
+
+	switch ft {
+	case smartling.FileTypeJSON:
+		err = json.Unmarshal(b, &v)
+	case smartling.FileTypeYAML:
+		err = yaml.Unmarshal(b, &v)
+	default:
+		return nil, fmt.Errorf("Can't parse translations of file type %s", ft)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	kv := map[string]string{}
+	flattenTranslations("", v, kv)
+
+	return kv, nil
+}
+
+func flattenTranslations(prefix string, v interface{}, kv map[string]string) {
+	join := func(k string) string {
+		if prefix == "" {
+			return k
+		}
+		return prefix + "." + k
+	}
+
+	switch t := v.(type) {
+	case string:
+		kv[prefix] = t
+	case map[string]interface{}:
+		for k, vv := range t {
+			flattenTranslations(join(k), vv, kv)
+		}
+	case map[interface{}]interface{}:
+		for k, vv := range t {
+			flattenTranslations(join(fmt.Sprint(k)), vv, kv)
+		}
+	case []interface{}:
+		for i, vv := range t {
+			flattenTranslations(join(fmt.Sprint(i)), vv, kv)
+		}
+	}
+}
+
+// localeLanguage returns the language part of a locale, e.g. "pt" for "pt-BR"
+func localeLanguage(locale string) string {
+	l := strings.ToLower(locale)
+	if i := strings.IndexAny(l, "-_"); i >= 0 {
+		return l[:i]
+	}
+	return l
+}
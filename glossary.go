@@ -0,0 +1,480 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"regexp"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/urfave/cli"
+)
+
+// Glossary is a Smartling glossary
+type Glossary struct {
+	GlossaryUID    string `json:"glossaryUid"`
+	GlossaryName   string `json:"glossaryName"`
+	Description    string
+	SourceLocaleID string   `json:"sourceLocaleId"`
+	LocaleIDs      []string `json:"localeIds"`
+}
+
+// GlossaryEntry is a term in a glossary with its translations, including the
+// term in the source locale
+type GlossaryEntry struct {
+	EntryUID     string         `json:"entryUid,omitempty"`
+	Definition   string         `json:"definition,omitempty"`
+	PartOfSpeech string         `json:"partOfSpeech,omitempty"`
+	Translations []GlossaryTerm `json:"translations"`
+}
+
+type GlossaryTerm struct {
+	LocaleID       string `json:"localeId"`
+	Term           string `json:"term"`
+	Notes          string `json:"notes,omitempty"`
+	CaseSensitive  bool   `json:"caseSensitive,omitempty"`
+	DoNotTranslate bool   `json:"doNotTranslate,omitempty"`
+}
+
+func (e GlossaryEntry) term(locale string) (GlossaryTerm, bool) {
+	for _, t := range e.Translations {
+		if t.LocaleID == locale && t.Term != "" {
+			return t, true
+		}
+	}
+	return GlossaryTerm{}, false
+}
+
+var accountUID string
+
+// AccountUID returns the account the project belongs to, which glossaries
+// are scoped to
+func (c *FaultTolerantClient) AccountUID() string {
+	if accountUID == "" {
+		pd, err := c.ProjectDetails()
+		logAndQuitIfError(err)
+		accountUID = pd.AccountUID
+	}
+
+	return accountUID
+}
+
+func (c *FaultTolerantClient) glossaryEndpoint(path string) string {
+	return fmt.Sprintf("/glossary-api/v3/accounts/%s/glossaries%s", c.AccountUID(), path)
+}
+
+func (c *FaultTolerantClient) ListGlossaries() (gg []Glossary, err error) {
+	c.execWithRetry(func() error {
+		var r struct {
+			Items []Glossary
+		}
+		err = c.requestJSON("POST", c.glossaryEndpoint("/search"), nil, map[string]interface{}{}, &r)
+		gg = r.Items
+		return err
+	})
+	return
+}
+
+func (c *FaultTolerantClient) GetGlossary(glossaryUID string) (g *Glossary, err error) {
+	c.execWithRetry(func() error {
+		g = &Glossary{}
+		err = c.requestJSON("GET", c.glossaryEndpoint("/"+glossaryUID), nil, nil, g)
+		return err
+	})
+	return
+}
+
+func (c *FaultTolerantClient) GlossaryEntries(glossaryUID string) (entries []GlossaryEntry, err error) {
+	c.execWithRetry(func() error {
+		entries = []GlossaryEntry{}
+		for offset := 0; ; {
+			var r struct {
+				TotalCount int
+				Items      []GlossaryEntry
+			}
+			err = c.requestJSON("POST", c.glossaryEndpoint("/"+glossaryUID+"/entries/search"), nil, map[string]interface{}{
+				"paging": map[string]int{"offset": offset, "limit": 500},
+			}, &r)
+			if err != nil {
+				return err
+			}
+
+			entries = append(entries, r.Items...)
+			offset += len(r.Items)
+			if len(r.Items) == 0 || offset >= r.TotalCount {
+				return nil
+			}
+		}
+	})
+	return
+}
+
+func (c *FaultTolerantClient) AddGlossaryEntry(glossaryUID string, e GlossaryEntry) (err error) {
+	c.execWithRetry(func() error {
+		err = c.requestJSON("POST", c.glossaryEndpoint("/"+glossaryUID+"/entries"), nil, e, nil)
+		return err
+	})
+	return
+}
+
+// glossaryUID returns the glossary to use: from the flag, the config, or the
+// account's only glossary
+func glossaryUID(c *cli.Context) string {
+	if c.String("glossary") != "" {
+		return c.String("glossary")
+	}
+	if ProjectConfig != nil && ProjectConfig.GlossaryUID != "" {
+		return ProjectConfig.GlossaryUID
+	}
+
+	gg, err := client.ListGlossaries()
+	logAndQuitIfError(err)
+	if len(gg) != 1 {
+		log.Fatalln("Specify the glossary with --glossary or glossary_uid in the config")
+	}
+
+	return gg[0].GlossaryUID
+}
+
+var glossaryFlag = cli.StringFlag{
+	Name:  "glossary",
+	Usage: "Glossary UID. Defaults to glossary_uid in the config, or the account's only glossary",
+}
+
+var glossaryFormatFlag = cli.StringFlag{
+	Name:  "format",
+	Usage: "File format, tbx or csv. Defaults to the file extension, or tbx",
+}
+
+func glossaryFormat(c *cli.Context, filename string) string {
+	format, err := fileFormat(c, filename, "tbx", "csv")
+	logAndQuitIfError(err)
+
+	return format
+}
+
+var GlossaryCommand = cli.Command{
+	Name:   "glossary",
+	Usage:  "manage the glossary",
+	Before: cmdBefore,
+	Subcommands: []cli.Command{
+		glossaryLsCommand,
+		glossaryExportCommand,
+		glossaryImportCommand,
+		glossaryAddCommand,
+	},
+}
+
+var glossaryLsCommand = cli.Command{
+	Name:  "ls",
+	Usage: "lists the glossaries",
+	Action: func(c *cli.Context) {
+		if len(c.Args()) != 0 {
+			log.Println("Wrong number of arguments")
+			log.Fatalln("Usage: ls")
+		}
+
+		gg, err := client.ListGlossaries()
+		logAndQuitIfError(err)
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		for _, g := range gg {
+			fmt.Fprintf(w, "%s\t%s\t%s\n", g.GlossaryUID, g.GlossaryName, strings.Join(g.LocaleIDs, ","))
+		}
+		w.Flush()
+	},
+}
+
+var glossaryExportCommand = cli.Command{
+	Name:        "export",
+	Usage:       "exports the glossary as TBX or CSV",
+	Description: "export [--format tbx|csv] [--locale <locale>]... [<file>]",
+	Flags:       []cli.Flag{glossaryFlag, glossaryFormatFlag, localeFlag},
+	Action: func(c *cli.Context) {
+		if len(c.Args()) > 1 {
+			log.Println("Wrong number of arguments")
+			log.Fatalln("Usage: export [--format tbx|csv] [--locale <locale>]... [<file>]")
+		}
+
+		uid := glossaryUID(c)
+		g, err := client.GetGlossary(uid)
+		logAndQuitIfError(err)
+		entries, err := client.GlossaryEntries(uid)
+		logAndQuitIfError(err)
+
+		locales := c.StringSlice("locale")
+		if len(locales) == 0 {
+			locales = g.LocaleIDs
+		}
+		locales = append([]string{g.SourceLocaleID}, locales...)
+
+		var w io.Writer = os.Stdout
+		if c.Args().Get(0) != "" {
+			f, err := os.Create(c.Args().Get(0))
+			logAndQuitIfError(err)
+			defer f.Close()
+			w = f
+		}
+
+		if glossaryFormat(c, c.Args().Get(0)) == "csv" {
+			writeGlossaryCSV(w, entries, locales)
+		} else {
+			writeGlossaryTBX(w, entries, locales)
+		}
+	},
+}
+
+var glossaryImportCommand = cli.Command{
+	Name:        "import",
+	Usage:       "imports entries into the glossary from TBX or CSV",
+	Description: "import [--format tbx|csv] <file>",
+	Flags:       []cli.Flag{glossaryFlag, glossaryFormatFlag},
+	Action: func(c *cli.Context) {
+		if len(c.Args()) != 1 {
+			log.Println("Wrong number of arguments")
+			log.Fatalln("Usage: import [--format tbx|csv] <file>")
+		}
+
+		f, err := os.Open(c.Args().Get(0))
+		logAndQuitIfError(err)
+		defer f.Close()
+
+		var entries []GlossaryEntry
+		if glossaryFormat(c, c.Args().Get(0)) == "csv" {
+			entries, err = readGlossaryCSV(f)
+		} else {
+			entries, err = readGlossaryTBX(f)
+		}
+		logAndQuitIfError(err)
+
+		uid := glossaryUID(c)
+		for i, e := range entries {
+			logAndQuitIfError(client.AddGlossaryEntry(uid, e))
+			log.Printf("[%d/%d] Imported %s\n", i+1, len(entries), e.Translations[0].Term)
+		}
+	},
+}
+
+var glossaryAddCommand = cli.Command{
+	Name:        "add",
+	Usage:       "adds a term to the glossary",
+	Description: "add [--definition <text>] [--translation <locale>=<term>]... <term>",
+	Flags: []cli.Flag{
+		glossaryFlag,
+		cli.StringFlag{
+			Name: "definition",
+		},
+		cli.StringSliceFlag{
+			Name:  "translation",
+			Usage: "Translation of the term in the format <locale>=<term>, can be repeated",
+		},
+		cli.BoolFlag{
+			Name:  "do-not-translate",
+			Usage: "The term must not be translated",
+		},
+	},
+	Action: func(c *cli.Context) {
+		if len(c.Args()) != 1 {
+			log.Println("Wrong number of arguments")
+			log.Fatalln("Usage: add [--definition <text>] [--translation <locale>=<term>]... <term>")
+		}
+
+		uid := glossaryUID(c)
+		g, err := client.GetGlossary(uid)
+		logAndQuitIfError(err)
+
+		e := GlossaryEntry{
+			Definition: c.String("definition"),
+			Translations: []GlossaryTerm{{
+				LocaleID:       g.SourceLocaleID,
+				Term:           c.Args().Get(0),
+				DoNotTranslate: c.Bool("do-not-translate"),
+			}},
+		}
+		for _, t := range c.StringSlice("translation") {
+			parts := strings.SplitN(t, "=", 2)
+			if len(parts) != 2 {
+				log.Fatalln("translation must be in the format --translation=de-DE=Begriff")
+			}
+			e.Translations = append(e.Translations, GlossaryTerm{LocaleID: parts[0], Term: parts[1]})
+		}
+
+		logAndQuitIfError(client.AddGlossaryEntry(uid, e))
+	},
+}
+
+// The CSV format has a header row of "definition" and locale columns, and a
+// row per entry. The first locale column is the source locale.
+
+func writeGlossaryCSV(w io.Writer, entries []GlossaryEntry, locales []string) {
+	rows := [][]string{append([]string{"definition"}, locales...)}
+	for _, e := range entries {
+		row := []string{e.Definition}
+		for _, l := range locales {
+			t, _ := e.term(l)
+			row = append(row, t.Term)
+		}
+		rows = append(rows, row)
+	}
+
+	logAndQuitIfError(csv.NewWriter(w).WriteAll(rows))
+}
+
+func readGlossaryCSV(r io.Reader) ([]GlossaryEntry, error) {
+	rows, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+
+	header := rows[0]
+	entries := []GlossaryEntry{}
+	for _, row := range rows[1:] {
+		e := GlossaryEntry{}
+		for i, col := range header {
+			switch {
+			case col == "definition":
+				e.Definition = row[i]
+			case row[i] != "":
+				e.Translations = append(e.Translations, GlossaryTerm{LocaleID: col, Term: row[i]})
+			}
+		}
+		if len(e.Translations) > 0 {
+			entries = append(entries, e)
+		}
+	}
+
+	return entries, nil
+}
+
+// A minimal TBX-Basic document
+
+type tbxDocument struct {
+	XMLName xml.Name       `xml:"martif"`
+	Type    string         `xml:"type,attr"`
+	Entries []tbxTermEntry `xml:"text>body>termEntry"`
+}
+
+type tbxTermEntry struct {
+	ID       string       `xml:"id,attr,omitempty"`
+	Descrips []tbxDescrip `xml:"descrip"`
+	LangSets []tbxLangSet `xml:"langSet"`
+}
+
+type tbxDescrip struct {
+	Type  string `xml:"type,attr"`
+	Value string `xml:",chardata"`
+}
+
+type tbxLangSet struct {
+	Lang string `xml:"http://www.w3.org/XML/1998/namespace lang,attr"`
+	Term string `xml:"tig>term"`
+}
+
+func writeGlossaryTBX(w io.Writer, entries []GlossaryEntry, locales []string) {
+	doc := tbxDocument{Type: "TBX-Basic"}
+	for _, e := range entries {
+		te := tbxTermEntry{ID: e.EntryUID}
+		if e.Definition != "" {
+			te.Descrips = append(te.Descrips, tbxDescrip{Type: "definition", Value: e.Definition})
+		}
+		for _, l := range locales {
+			if t, ok := e.term(l); ok {
+				te.LangSets = append(te.LangSets, tbxLangSet{Lang: l, Term: t.Term})
+			}
+		}
+		doc.Entries = append(doc.Entries, te)
+	}
+
+	b, err := xml.MarshalIndent(doc, "", "  ")
+	logAndQuitIfError(err)
+
+	fmt.Fprintln(w, xml.Header+string(b))
+}
+
+func readGlossaryTBX(r io.Reader) ([]GlossaryEntry, error) {
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc tbxDocument
+	if err := xml.Unmarshal(b, &doc); err != nil {
+		return nil, err
+	}
+
+	entries := []GlossaryEntry{}
+	for _, te := range doc.Entries {
+		e := GlossaryEntry{}
+		for _, d := range te.Descrips {
+			if d.Type == "definition" {
+				e.Definition = d.Value
+			}
+		}
+		for _, ls := range te.LangSets {
+			e.Translations = append(e.Translations, GlossaryTerm{LocaleID: ls.Lang, Term: ls.Term})
+		}
+		if len(e.Translations) > 0 {
+			entries = append(entries, e)
+		}
+	}
+
+	return entries, nil
+}
+
+// glossaryCheck returns a lint check flagging translations where a glossary
+// term in the source string isn't translated with the approved term
+func glossaryCheck(entries []GlossaryEntry, sourceLocale string) lintCheck {
+	// the check runs for every string and locale, so the terms are compiled
+	// once, keyed by entry then locale
+	terms := make([]map[string]*regexp.Regexp, len(entries))
+	for i, e := range entries {
+		terms[i] = map[string]*regexp.Regexp{}
+		for _, t := range e.Translations {
+			if _, ok := terms[i][t.LocaleID]; !ok && t.Term != "" {
+				terms[i][t.LocaleID] = termRegexp(t.Term, t.CaseSensitive)
+			}
+		}
+	}
+
+	return func(source, translation, locale string) []string {
+		msgs := []string{}
+		for i, e := range entries {
+			st, ok := e.term(sourceLocale)
+			if !ok || !terms[i][sourceLocale].MatchString(source) {
+				continue
+			}
+
+			if st.DoNotTranslate {
+				if !terms[i][sourceLocale].MatchString(translation) {
+					msgs = append(msgs, fmt.Sprintf("glossary term %q must not be translated", st.Term))
+				}
+				continue
+			}
+
+			tt, ok := e.term(locale)
+			if ok && !terms[i][locale].MatchString(translation) {
+				msgs = append(msgs, fmt.Sprintf("glossary term %q should be translated as %q", st.Term, tt.Term))
+			}
+		}
+
+		return msgs
+	}
+}
+
+// termRegexp matches the term as a whole word
+func termRegexp(term string, caseSensitive bool) *regexp.Regexp {
+	expr := `(^|\P{L})` + regexp.QuoteMeta(term) + `($|\P{L})`
+	if !caseSensitive {
+		expr = "(?i)" + expr
+	}
+
+	return regexp.MustCompile(expr)
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGlossaryCheck(t *testing.T) {
+	check := glossaryCheck([]GlossaryEntry{
+		{Translations: []GlossaryTerm{
+			{LocaleID: "en-US", Term: "cart"},
+			{LocaleID: "de-DE", Term: "Warenkorb"},
+		}},
+		{Translations: []GlossaryTerm{
+			{LocaleID: "en-US", Term: "Acme", CaseSensitive: true, DoNotTranslate: true},
+		}},
+	}, "en-US")
+
+	tests := []struct {
+		source, translation string
+		want                []string
+	}{
+		{"Your cart", "Ihr Warenkorb", []string{}},
+		{"Your Cart", "Ihr Einkaufswagen", []string{`glossary term "cart" should be translated as "Warenkorb"`}},
+		{"Carts", "Einkaufswagen", []string{}},
+		{"Acme cart", "Acme Warenkorb", []string{}},
+		{"Acme", "acme", []string{`glossary term "Acme" must not be translated`}},
+	}
+	for _, tt := range tests {
+		if got := check(tt.source, tt.translation, "de-DE"); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("check(%q, %q) = %v, want %v", tt.source, tt.translation, got, tt.want)
+		}
+	}
+}
@@ -1,12 +1,21 @@
 module github.com/99designs/smartling
 
-go 1.12
+go 1.17
 
 require (
 	github.com/99designs/api-sdk-go v0.0.0-20180919023439-621ccf0ab7a6
+	github.com/fsnotify/fsnotify v1.6.0
+	github.com/urfave/cli v1.22.5
+	gopkg.in/yaml.v2 v2.2.2
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+require (
 	github.com/Smartling/api-sdk-go v0.0.0-20200428111932-35139033a212 // indirect
+	github.com/cpuguy83/go-md2man/v2 v2.0.0-20190314233015-f79a8a8ca69d // indirect
 	github.com/kr/pretty v0.1.0 // indirect
-	github.com/urfave/cli v1.22.5
+	github.com/russross/blackfriday/v2 v2.0.1 // indirect
+	github.com/shurcooL/sanitized_anchor_name v1.0.0 // indirect
+	golang.org/x/sys v0.13.0 // indirect
 	gopkg.in/check.v1 v1.0.0-20180628173108-788fd7840127 // indirect
-	gopkg.in/yaml.v2 v2.2.2
 )
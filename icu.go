@@ -0,0 +1,245 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// icuArgument is an argument found in an ICU MessageFormat message,
+// e.g. {count, plural, one {# item} other {# items}}
+type icuArgument struct {
+	Name      string
+	Type      string
+	Selectors []string
+}
+
+func (a icuArgument) hasSelector(s string) bool {
+	for _, sel := range a.Selectors {
+		if sel == s {
+			return true
+		}
+	}
+	return false
+}
+
+type icuParser struct {
+	s    []rune
+	pos  int
+	args []icuArgument
+}
+
+// parseICUMessage parses an ICU MessageFormat message and returns all the
+// arguments it contains, including those nested in plural and select
+// sub-messages
+func parseICUMessage(msg string) ([]icuArgument, error) {
+	p := &icuParser{s: []rune(msg)}
+	if err := p.parseMessage(0); err != nil {
+		return nil, err
+	}
+
+	return p.args, nil
+}
+
+func (p *icuParser) eof() bool {
+	return p.pos >= len(p.s)
+}
+
+func (p *icuParser) peek() rune {
+	if p.eof() {
+		return 0
+	}
+	return p.s[p.pos]
+}
+
+func (p *icuParser) skipWhitespace() {
+	for !p.eof() && unicode.IsSpace(p.peek()) {
+		p.pos++
+	}
+}
+
+func (p *icuParser) errorf(format string, a ...interface{}) error {
+	return fmt.Errorf("%s at position %d", fmt.Sprintf(format, a...), p.pos)
+}
+
+func (p *icuParser) expect(r rune) error {
+	p.skipWhitespace()
+	if p.peek() != r {
+		if p.eof() {
+			return p.errorf("expected '%c' but message ended", r)
+		}
+		return p.errorf("expected '%c' but found '%c'", r, p.peek())
+	}
+	p.pos++
+	return nil
+}
+
+// readWord reads until whitespace or one of the stop characters
+func (p *icuParser) readWord(stop string) string {
+	start := p.pos
+	for !p.eof() && !unicode.IsSpace(p.peek()) && !strings.ContainsRune(stop, p.peek()) {
+		p.pos++
+	}
+	return string(p.s[start:p.pos])
+}
+
+func (p *icuParser) parseMessage(depth int) error {
+	for !p.eof() {
+		switch p.peek() {
+		case '\'':
+			p.skipQuoted()
+		case '{':
+			if err := p.parseArgument(depth); err != nil {
+				return err
+			}
+		case '}':
+			if depth == 0 {
+				return p.errorf("unmatched '}'")
+			}
+			return nil
+		default:
+			p.pos++
+		}
+	}
+
+	if depth > 0 {
+		return p.errorf("unclosed '{'")
+	}
+
+	return nil
+}
+
+// skipQuoted skips apostrophe quoting: a doubled apostrophe is literal, and an
+// apostrophe before a syntax character quotes everything up to the next one
+func (p *icuParser) skipQuoted() {
+	p.pos++
+	if p.eof() {
+		return
+	}
+	if p.peek() == '\'' {
+		p.pos++
+		return
+	}
+	if !strings.ContainsRune("{}#|", p.peek()) {
+		return
+	}
+	for !p.eof() {
+		r := p.peek()
+		p.pos++
+		if r == '\'' {
+			if p.peek() == '\'' {
+				p.pos++
+				continue
+			}
+			return
+		}
+	}
+}
+
+func (p *icuParser) parseArgument(depth int) error {
+	p.pos++ // {
+	p.skipWhitespace()
+
+	arg := icuArgument{Name: p.readWord(",{}")}
+	if arg.Name == "" {
+		return p.errorf("missing argument name")
+	}
+
+	p.skipWhitespace()
+	if p.peek() == '}' {
+		p.pos++
+		p.args = append(p.args, arg)
+		return nil
+	}
+	if err := p.expect(','); err != nil {
+		return err
+	}
+
+	p.skipWhitespace()
+	arg.Type = p.readWord(",{}")
+	if arg.Type == "" {
+		return p.errorf("missing type for argument %s", arg.Name)
+	}
+
+	switch arg.Type {
+	case "plural", "selectordinal", "select":
+		if err := p.expect(','); err != nil {
+			return err
+		}
+		if err := p.parseSelectors(&arg, depth); err != nil {
+			return err
+		}
+	default:
+		if err := p.skipArgumentStyle(); err != nil {
+			return err
+		}
+	}
+
+	p.args = append(p.args, arg)
+	return nil
+}
+
+func (p *icuParser) parseSelectors(arg *icuArgument, depth int) error {
+	for {
+		p.skipWhitespace()
+		if p.eof() {
+			return p.errorf("unclosed %s argument %s", arg.Type, arg.Name)
+		}
+		if p.peek() == '}' {
+			p.pos++
+			break
+		}
+
+		sel := p.readWord("{}")
+		if sel == "" {
+			return p.errorf("missing selector in %s argument %s", arg.Type, arg.Name)
+		}
+		if strings.HasPrefix(sel, "offset:") && arg.Type != "select" {
+			continue
+		}
+		if arg.hasSelector(sel) {
+			return p.errorf("duplicate selector %s in %s argument %s", sel, arg.Type, arg.Name)
+		}
+		arg.Selectors = append(arg.Selectors, sel)
+
+		if err := p.expect('{'); err != nil {
+			return err
+		}
+		if err := p.parseMessage(depth + 1); err != nil {
+			return err
+		}
+		if err := p.expect('}'); err != nil {
+			return err
+		}
+	}
+
+	if len(arg.Selectors) == 0 {
+		return p.errorf("%s argument %s has no selectors", arg.Type, arg.Name)
+	}
+
+	return nil
+}
+
+// skipArgumentStyle skips the optional style of a simple argument such as
+// {n, number, ::currency/EUR}
+func (p *icuParser) skipArgumentStyle() error {
+	nesting := 0
+	for !p.eof() {
+		switch p.peek() {
+		case '\'':
+			p.skipQuoted()
+			continue
+		case '{':
+			nesting++
+		case '}':
+			if nesting == 0 {
+				p.pos++
+				return nil
+			}
+			nesting--
+		}
+		p.pos++
+	}
+
+	return p.errorf("unclosed '{'")
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseICUMessage(t *testing.T) {
+	tests := []struct {
+		msg  string
+		args []icuArgument
+		err  string
+	}{
+		{msg: "Hello", args: nil},
+		{msg: "Hello {name}", args: []icuArgument{{Name: "name"}}},
+		{msg: "{n, number, ::currency/EUR}", args: []icuArgument{{Name: "n", Type: "number"}}},
+
+		// apostrophe quoting
+		{msg: "It''s {name}", args: []icuArgument{{Name: "name"}}},
+		{msg: "'{literal}' {name}", args: []icuArgument{{Name: "name"}}},
+		{msg: "'{unbalanced' text", args: nil},
+		{msg: "don't {name}", args: []icuArgument{{Name: "name"}}},
+
+		// nested plural and select
+		{
+			msg: "{gender, select, female {{n, plural, one {# her} other {# hers}}} other {{n, plural, =0 {none} other {#}}}}",
+			args: []icuArgument{
+				{Name: "n", Type: "plural", Selectors: []string{"one", "other"}},
+				{Name: "n", Type: "plural", Selectors: []string{"=0", "other"}},
+				{Name: "gender", Type: "select", Selectors: []string{"female", "other"}},
+			},
+		},
+		{
+			msg:  "{n, plural, offset:1 =0 {none} =1 {one} other {# more}}",
+			args: []icuArgument{{Name: "n", Type: "plural", Selectors: []string{"=0", "=1", "other"}}},
+		},
+		{
+			msg:  "{n, selectordinal, one {#st} two {#nd} few {#rd} other {#th}}",
+			args: []icuArgument{{Name: "n", Type: "selectordinal", Selectors: []string{"one", "two", "few", "other"}}},
+		},
+
+		// unbalanced braces
+		{msg: "Hello {name", err: "but message ended"},
+		{msg: "Hello name}", err: "unmatched '}'"},
+		{msg: "{n, plural, one {# item} other {# items}", err: "unclosed plural argument n"},
+		{msg: "{n, plural, one {# item other {# items}}", err: "expected ','"},
+		{msg: "{}", err: "missing argument name"},
+
+		// selectors
+		{msg: "{n, plural, one {a} one {b} other {c}}", err: "duplicate selector one in plural argument n"},
+		{msg: "{n, plural, =1 {a} =1 {b} other {c}}", err: "duplicate selector =1 in plural argument n"},
+		{msg: "{g, select, }", err: "select argument g has no selectors"},
+	}
+
+	for _, tt := range tests {
+		args, err := parseICUMessage(tt.msg)
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("parseICUMessage(%q) error = %v, want %q", tt.msg, err, tt.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseICUMessage(%q) error = %v", tt.msg, err)
+			continue
+		}
+		if !reflect.DeepEqual(args, tt.args) {
+			t.Errorf("parseICUMessage(%q) = %+v, want %+v", tt.msg, args, tt.args)
+		}
+	}
+}
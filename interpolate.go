@@ -0,0 +1,100 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// interpolateEnv replaces ${VAR} with the value of the environment variable,
+// which must be set, and ${VAR:-default} with the value or the default if
+// it's unset or empty. $$ is a literal $.
+func interpolateEnv(s string) (string, error) {
+	var out strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '$' || i+1 == len(s) {
+			out.WriteByte(s[i])
+			continue
+		}
+
+		switch s[i+1] {
+		case '$':
+			out.WriteByte('$')
+			i++
+		case '{':
+			end := strings.IndexByte(s[i:], '}')
+			if end == -1 {
+				return "", fmt.Errorf("Unterminated ${ in %q", s)
+			}
+			v, err := expandVar(s[i+2 : i+end])
+			if err != nil {
+				return "", err
+			}
+			out.WriteString(v)
+			i += end
+		default:
+			out.WriteByte('$')
+		}
+	}
+
+	return out.String(), nil
+}
+
+func expandVar(expr string) (string, error) {
+	name, def, hasDefault := expr, "", false
+	if n := strings.Index(expr, ":-"); n != -1 {
+		name, def, hasDefault = expr[:n], expr[n+2:], true
+	}
+	if name == "" {
+		return "", fmt.Errorf("Missing variable name in ${%s}", expr)
+	}
+
+	v, ok := os.LookupEnv(name)
+	if hasDefault && v == "" {
+		return def, nil
+	}
+	if !ok {
+		return "", fmt.Errorf("Environment variable %s is not set", name)
+	}
+
+	return v, nil
+}
+
+// interpolateConfig runs interpolateEnv on the credentials and paths of a
+// config and its projects. Other strings such as parser_config and
+// pull_file_path are left alone, as they can contain $ for other reasons,
+// e.g. placeholder regexps.
+func interpolateConfig(c *Config) error {
+	fields := []*string{&c.ApiKey, &c.UserID, &c.ProjectID, &c.GlossaryUID, &c.Overrides}
+	lists := [][]string{c.FileGlobs, c.Includes, c.Locales}
+	for i := range c.Context {
+		fields = append(fields, &c.Context[i].Screenshots)
+		lists = append(lists, c.Context[i].FileGlobs)
+	}
+	for i := range c.Formats {
+		lists = append(lists, c.Formats[i].FileGlobs)
+	}
+	for _, l := range lists {
+		for i := range l {
+			fields = append(fields, &l[i])
+		}
+	}
+
+	for _, f := range fields {
+		s, err := interpolateEnv(*f)
+		if err != nil {
+			return err
+		}
+		*f = s
+	}
+
+	for _, name := range c.projectNames() {
+		if pc := c.Projects[name]; pc != nil {
+			if err := interpolateConfig(pc); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
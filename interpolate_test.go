@@ -0,0 +1,101 @@
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestInterpolateEnv(t *testing.T) {
+	t.Setenv("SMARTLING_TEST_SET", "value")
+	t.Setenv("SMARTLING_TEST_EMPTY", "")
+
+	tests := []struct {
+		s, want, err string
+	}{
+		{s: "plain", want: "plain"},
+		{s: "${SMARTLING_TEST_SET}/files", want: "value/files"},
+		{s: "a$$b", want: "a$b"},
+		{s: "$${SMARTLING_TEST_SET}", want: "${SMARTLING_TEST_SET}"},
+		{s: "cost $5 and $", want: "cost $5 and $"},
+		{s: "${SMARTLING_TEST_UNSET:-default}", want: "default"},
+		{s: "${SMARTLING_TEST_EMPTY:-default}", want: "default"},
+		{s: "${SMARTLING_TEST_SET:-default}", want: "value"},
+		{s: "${SMARTLING_TEST_UNSET:-}", want: ""},
+		{s: "${SMARTLING_TEST_EMPTY}", want: ""},
+		{s: "${SMARTLING_TEST_UNSET}", err: "Environment variable SMARTLING_TEST_UNSET is not set"},
+		{s: "${SMARTLING_TEST_SET", err: "Unterminated ${"},
+		{s: "${}", err: "Missing variable name"},
+		{s: "${:-default}", err: "Missing variable name"},
+	}
+
+	for _, tt := range tests {
+		got, err := interpolateEnv(tt.s)
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("interpolateEnv(%q) error = %v, want %q", tt.s, err, tt.err)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("interpolateEnv(%q) = %q, %v, want %q", tt.s, got, err, tt.want)
+		}
+	}
+}
+
+func TestExpandVar(t *testing.T) {
+	t.Setenv("SMARTLING_TEST_SET", "value")
+
+	for expr, want := range map[string]string{
+		"SMARTLING_TEST_SET":          "value",
+		"SMARTLING_TEST_SET:-default": "value",
+		"SMARTLING_TEST_UNSET:-a:-b":  "a:-b",
+		"SMARTLING_TEST_UNSET:-${x}":  "${x}",
+		"SMARTLING_TEST_UNSET:-":      "",
+	} {
+		if got, err := expandVar(expr); err != nil || got != want {
+			t.Errorf("expandVar(%q) = %q, %v, want %q", expr, got, err, want)
+		}
+	}
+
+	if _, err := expandVar("SMARTLING_TEST_UNSET"); err == nil {
+		t.Error("expected an error for an unset variable")
+	}
+}
+
+func TestLoadConfigOnlyInterpolatesCredentialsAndPaths(t *testing.T) {
+	t.Setenv("SMARTLING_TEST_PROJECT", "project")
+	t.Setenv("SMARTLING_TEST_DIR", "web")
+
+	dir := t.TempDir()
+	yml := `project_id: "${SMARTLING_TEST_PROJECT}"
+files:
+  - "${SMARTLING_TEST_DIR}/*.json"
+parser_config:
+  placeholder_format_custom: "\\${[^}]+}|\\$$"
+pull_file_path: "{{ $x := .Locale }}{{ $x }}.json"
+projects:
+  app:
+    files:
+      - "${SMARTLING_TEST_DIR:-app}/app.json"
+`
+	if err := ioutil.WriteFile(filepath.Join(dir, "smartling.yml"), []byte(yml), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := loadConfig(filepath.Join(dir, "smartling.yml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if c.ProjectID != "project" || c.FileGlobs[0] != "web/*.json" || c.Projects["app"].FileGlobs[0] != "web/app.json" {
+		t.Errorf("expected the variables to be resolved, got %+v", c)
+	}
+	if got := c.ParserConfig["placeholder_format_custom"]; got != `\${[^}]+}|\$$` {
+		t.Errorf("got parser_config %q, want it unchanged", got)
+	}
+	if got := c.PullFilePath; got != "{{ $x := .Locale }}{{ $x }}.json" {
+		t.Errorf("got pull_file_path %q, want it unchanged", got)
+	}
+}
@@ -0,0 +1,299 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/99designs/api-sdk-go"
+	"github.com/urfave/cli"
+)
+
+// Job is a Smartling translation job
+type Job struct {
+	TranslationJobUID string
+	JobName           string
+	JobStatus         string
+	Description       string
+	DueDate           *smartling.UTC
+	TargetLocaleIDs   []string `json:"targetLocaleIds"`
+	CreatedDate       *smartling.UTC
+}
+
+func (j Job) dueDate() string {
+	if j.DueDate == nil {
+		return "-"
+	}
+	return j.DueDate.Format("2006-01-02 15:04")
+}
+
+type jobsList struct {
+	TotalCount int
+	Items      []Job
+}
+
+func (c *FaultTolerantClient) jobsEndpoint(path string) string {
+	return fmt.Sprintf("/jobs-api/v3/projects/%s/jobs%s", c.ProjectID, path)
+}
+
+func (c *FaultTolerantClient) ListJobs(name string) (jobs []Job, err error) {
+	c.execWithRetry(func() error {
+		jobs = []Job{}
+		for offset := 0; ; {
+			params := url.Values{"offset": {fmt.Sprint(offset)}}
+			if name != "" {
+				params.Set("jobName", name)
+			}
+
+			var list jobsList
+			err = c.requestJSON("GET", c.jobsEndpoint(""), params, nil, &list)
+			if err != nil {
+				return err
+			}
+
+			jobs = append(jobs, list.Items...)
+			offset += len(list.Items)
+			if len(list.Items) == 0 || offset >= list.TotalCount {
+				return nil
+			}
+		}
+	})
+	return
+}
+
+func (c *FaultTolerantClient) GetJob(jobUID string) (job *Job, err error) {
+	c.execWithRetry(func() error {
+		job = &Job{}
+		err = c.requestJSON("GET", c.jobsEndpoint("/"+jobUID), nil, nil, job)
+		return err
+	})
+	return
+}
+
+func (c *FaultTolerantClient) CreateJob(name, description string, due time.Time, locales []string) (job *Job, err error) {
+	req := map[string]interface{}{
+		"jobName":         name,
+		"targetLocaleIds": locales,
+	}
+	if description != "" {
+		req["description"] = description
+	}
+	if !due.IsZero() {
+		req["dueDate"] = smartling.UTC{Time: due.UTC()}
+	}
+
+	c.execWithRetry(func() error {
+		job = &Job{}
+		err = c.requestJSON("POST", c.jobsEndpoint(""), nil, req, job)
+		return err
+	})
+	return
+}
+
+func (c *FaultTolerantClient) AddFileToJob(jobUID, fileUri string, locales []string) (err error) {
+	c.execWithRetry(func() error {
+		err = c.requestJSON("POST", c.jobsEndpoint("/"+jobUID+"/file/add"), nil, map[string]interface{}{
+			"fileUri":         fileUri,
+			"targetLocaleIds": locales,
+		}, nil)
+		return err
+	})
+	return
+}
+
+func (c *FaultTolerantClient) AuthorizeJob(jobUID string) (err error) {
+	c.execWithRetry(func() error {
+		err = c.requestJSON("POST", c.jobsEndpoint("/"+jobUID+"/authorize"), nil, map[string]interface{}{}, nil)
+		return err
+	})
+	return
+}
+
+func (c *FaultTolerantClient) CancelJob(jobUID, reason string) (err error) {
+	c.execWithRetry(func() error {
+		err = c.requestJSON("POST", c.jobsEndpoint("/"+jobUID+"/cancel"), nil, map[string]interface{}{
+			"reason": reason,
+		}, nil)
+		return err
+	})
+	return
+}
+
+// findOrCreateJob reuses an active job with the same name, or creates one
+func findOrCreateJob(name string, due time.Time, locales []string) *Job {
+	jobs, err := client.ListJobs(name)
+	logAndQuitIfError(err)
+
+	for _, j := range jobs {
+		if j.JobName == name && j.JobStatus != "CANCELLED" && j.JobStatus != "CLOSED" && j.JobStatus != "COMPLETED" {
+			log.Println("Using job", j.TranslationJobUID)
+			return &j
+		}
+	}
+
+	j, err := client.CreateJob(name, "", due, locales)
+	logAndQuitIfError(err)
+	log.Println("Created job", j.TranslationJobUID)
+
+	return j
+}
+
+// parseDueDate accepts either a date or a RFC3339 timestamp
+func parseDueDate(s string) time.Time {
+	if s == "" {
+		return time.Time{}
+	}
+
+	t, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		t, err = time.Parse(time.RFC3339, s)
+	}
+	if err != nil {
+		log.Fatalf("Invalid due date %s, use the format 2006-01-02 or 2006-01-02T15:04:05Z\n", s)
+	}
+
+	return t
+}
+
+var dueFlag = cli.StringFlag{
+	Name:  "due",
+	Usage: "Due date of the job e.g. 2026-11-01",
+}
+
+var JobCommand = cli.Command{
+	Name:   "job",
+	Usage:  "manage translation jobs",
+	Before: cmdBefore,
+	Subcommands: []cli.Command{
+		jobCreateCommand,
+		jobLsCommand,
+		jobShowCommand,
+		jobAddFilesCommand,
+		jobAuthorizeCommand,
+		jobCancelCommand,
+	},
+}
+
+var jobCreateCommand = cli.Command{
+	Name:        "create",
+	Usage:       "creates a job",
+	Description: "create [--due <date>] [--locale <locale>]... <name>",
+	Flags: []cli.Flag{
+		dueFlag,
+		localeFlag,
+		cli.StringFlag{
+			Name: "description",
+		},
+	},
+	Action: func(c *cli.Context) {
+		if len(c.Args()) != 1 {
+			log.Println("Wrong number of arguments")
+			log.Fatalln("Usage: create [--due <date>] [--locale <locale>]... <name>")
+		}
+
+		j, err := client.CreateJob(c.Args().Get(0), c.String("description"), parseDueDate(c.String("due")), localesOrAll(c))
+		logAndQuitIfError(err)
+
+		fmt.Println(j.TranslationJobUID)
+	},
+}
+
+var jobLsCommand = cli.Command{
+	Name:        "ls",
+	Usage:       "lists jobs",
+	Description: "ls [<name>]",
+	Action: func(c *cli.Context) {
+		if len(c.Args()) > 1 {
+			log.Println("Wrong number of arguments")
+			log.Fatalln("Usage: ls [<name>]")
+		}
+
+		jobs, err := client.ListJobs(c.Args().Get(0))
+		logAndQuitIfError(err)
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		for _, j := range jobs {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", j.TranslationJobUID, j.JobStatus, j.dueDate(), j.JobName)
+		}
+		w.Flush()
+	},
+}
+
+var jobShowCommand = cli.Command{
+	Name:        "show",
+	Usage:       "shows the details of a job",
+	Description: "show <job uid>",
+	Action: func(c *cli.Context) {
+		if len(c.Args()) != 1 {
+			log.Println("Wrong number of arguments")
+			log.Fatalln("Usage: show <job uid>")
+		}
+
+		j, err := client.GetJob(c.Args().Get(0))
+		logAndQuitIfError(err)
+
+		fmt.Println("Job UID     ", j.TranslationJobUID)
+		fmt.Println("Name        ", j.JobName)
+		fmt.Println("Status      ", j.JobStatus)
+		fmt.Println("Description ", j.Description)
+		fmt.Println("Due Date    ", j.dueDate())
+		fmt.Println("Locales     ", strings.Join(j.TargetLocaleIDs, ", "))
+	},
+}
+
+var jobAddFilesCommand = cli.Command{
+	Name:        "add-files",
+	Usage:       "adds remote files to a job",
+	Description: "add-files [--locale <locale>]... <job uid> <remote file>...",
+	Flags:       []cli.Flag{localeFlag},
+	Action: func(c *cli.Context) {
+		if len(c.Args()) < 2 {
+			log.Println("Wrong number of arguments")
+			log.Fatalln("Usage: add-files [--locale <locale>]... <job uid> <remote file>...")
+		}
+
+		jobUID := c.Args().Get(0)
+		locales := localesOrAll(c)
+		for _, remotepath := range c.Args()[1:] {
+			logAndQuitIfError(client.AddFileToJob(jobUID, remotepath, locales))
+			fmt.Println("Added", remotepath)
+		}
+	},
+}
+
+var jobAuthorizeCommand = cli.Command{
+	Name:        "authorize",
+	Usage:       "authorizes a job for translation",
+	Description: "authorize <job uid>",
+	Action: func(c *cli.Context) {
+		if len(c.Args()) != 1 {
+			log.Println("Wrong number of arguments")
+			log.Fatalln("Usage: authorize <job uid>")
+		}
+
+		logAndQuitIfError(client.AuthorizeJob(c.Args().Get(0)))
+	},
+}
+
+var jobCancelCommand = cli.Command{
+	Name:        "cancel",
+	Usage:       "cancels a job",
+	Description: "cancel [--reason <reason>] <job uid>",
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name: "reason",
+		},
+	},
+	Action: func(c *cli.Context) {
+		if len(c.Args()) != 1 {
+			log.Println("Wrong number of arguments")
+			log.Fatalln("Usage: cancel [--reason <reason>] <job uid>")
+		}
+
+		logAndQuitIfError(client.CancelJob(c.Args().Get(0), c.String("reason")))
+	},
+}
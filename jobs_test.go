@@ -0,0 +1,106 @@
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestJobCreate(t *testing.T) {
+	f := newFakeSmartling(t)
+	f.use(t, &Config{})
+	fj := f.handleJobs()
+
+	out := runCommand(t, JobCommand, "create", "--locale", "de-DE", "--due", "2026-11-01", "Release 1")
+
+	if strings.TrimSpace(out) != "job1" {
+		t.Errorf("got output %q, want the job UID", out)
+	}
+	if len(fj.jobs) != 1 {
+		t.Fatalf("got %d jobs, want 1", len(fj.jobs))
+	}
+	j := fj.jobs[0]
+	if j.JobName != "Release 1" || !reflect.DeepEqual(j.TargetLocaleIDs, []string{"de-DE"}) {
+		t.Errorf("got job %+v", j)
+	}
+	if j.DueDate == nil || j.DueDate.Format("2006-01-02") != "2026-11-01" {
+		t.Errorf("got due date %v, want 2026-11-01", j.DueDate)
+	}
+}
+
+func TestJobLs(t *testing.T) {
+	f := newFakeSmartling(t)
+	f.use(t, &Config{})
+	fj := f.handleJobs()
+	fj.add(Job{JobName: "Release 1", JobStatus: "IN_PROGRESS"})
+	fj.add(Job{JobName: "Release 2", JobStatus: "AWAITING_AUTHORIZATION"})
+
+	out := runCommand(t, JobCommand, "ls")
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want 2:\n%s", len(lines), out)
+	}
+	for i, want := range []string{"job1", "job2"} {
+		if !strings.HasPrefix(lines[i], want) {
+			t.Errorf("line %d is %q, want it to start with %s", i, lines[i], want)
+		}
+	}
+
+	out = runCommand(t, JobCommand, "ls", "Release 2")
+	if !strings.Contains(out, "job2") || strings.Contains(out, "job1") {
+		t.Errorf("got %q, want only job2", out)
+	}
+}
+
+func TestJobAddFiles(t *testing.T) {
+	f := newFakeSmartling(t)
+	f.use(t, &Config{Locales: []string{"de-DE"}})
+	fj := f.handleJobs()
+	fj.add(Job{JobName: "Release 1"})
+
+	runCommand(t, JobCommand, "add-files", "job1", "/a.json", "/b.json")
+
+	if got := fj.Files("job1"); !reflect.DeepEqual(got, []string{"/a.json", "/b.json"}) {
+		t.Errorf("got files %v, want [/a.json /b.json]", got)
+	}
+}
+
+func TestProjectPushJob(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a.json", "b.json"} {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(`{"hello": "Hello"}`), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	chdir(t, dir)
+
+	f := newFakeProject(t, &Config{path: ".", FileGlobs: []string{"*.json"}, Locales: []string{"de-DE", "fr-FR"}})
+	fj := f.handleJobs()
+	f.handle("POST", "/files-api/v2/projects/project/file", func(r fakeRequest) interface{} {
+		return map[string]interface{}{"stringCount": 1, "wordCount": 1}
+	})
+
+	out := runCommand(t, projectPushCommand, "--prefix", "/test", "--job", "Release 1")
+
+	if !strings.Contains(out, "job1") {
+		t.Errorf("got output %q, want the job UID", out)
+	}
+	if n := len(f.Requests("POST", "/files-api/v2/projects/project/file")); n != 2 {
+		t.Errorf("got %d uploads, want 2", n)
+	}
+	files := fj.Files("job1")
+	if len(files) != 2 || !strings.HasSuffix(files[0], "/a.json") || !strings.HasSuffix(files[1], "/b.json") {
+		t.Errorf("got job files %v, want the uploaded a.json and b.json", files)
+	}
+	if locales := fj.jobs[0].TargetLocaleIDs; !reflect.DeepEqual(locales, []string{"de-DE", "fr-FR"}) {
+		t.Errorf("got job locales %v, want [de-DE fr-FR]", locales)
+	}
+
+	// pushing again reuses the job
+	runCommand(t, projectPushCommand, "--prefix", "/test", "--job", "Release 1")
+	if len(fj.jobs) != 1 {
+		t.Errorf("got %d jobs, want the job to be reused", len(fj.jobs))
+	}
+}
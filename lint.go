@@ -0,0 +1,197 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/urfave/cli"
+)
+
+type lintIssue struct {
+	File    string
+	Key     string
+	Locale  string
+	Message string
+}
+
+// lintCheck checks a translated string against its source string and returns
+// a message for each problem found
+type lintCheck func(source, translation, locale string) []string
+
+var projectLintCommand = cli.Command{
+	Name:  "lint",
+	Usage: "check pulled translation files for problems",
+	Flags: []cli.Flag{
+		cli.BoolFlag{
+			Name:  "check-glossary",
+			Usage: "Check that glossary terms are translated with the approved terms",
+		},
+		glossaryFlag,
+	},
+	Action: func(c *cli.Context) {
+		if len(c.Args()) > 0 {
+			log.Println("Wrong number of arguments")
+			log.Fatalln("Usage: lint")
+		}
+
+		issues := []lintIssue{}
+		forEachProject(func() {
+			checks := []lintCheck{checkICUMessage}
+
+			if c.Bool("check-glossary") {
+				uid := glossaryUID(c)
+				g, err := client.GetGlossary(uid)
+				logAndQuitIfError(err)
+				entries, err := client.GlossaryEntries(uid)
+				logAndQuitIfError(err)
+				checks = append(checks, glossaryCheck(entries, g.SourceLocaleID))
+			}
+
+			locales := fetchLocales()
+			for _, projectFilepath := range ProjectConfig.Files() {
+				for _, locale := range locales {
+					issues = append(issues, lintProjectFile(projectFilepath, locale, checks)...)
+				}
+			}
+		})
+
+		if len(issues) == 0 {
+			fmt.Println("No problems found")
+			return
+		}
+
+		PrintLintIssues(issues)
+		log.Fatalf("\n%d problems found\n", len(issues))
+	},
+}
+
+func lintProjectFile(projectFilepath, locale string, checks []lintCheck) []lintIssue {
+	ft := filetypeForProjectFile(projectFilepath)
+	if !isParseableFileType(ft) {
+		return nil
+	}
+
+	fp := localPullFilePath(projectFilepath, locale)
+	if _, err := os.Stat(fp); err != nil {
+		log.Println("Skipping", fp, "as it hasn't been pulled")
+		return nil
+	}
+
+	source, err := parseTranslations(readFile(projectFilepath), ft)
+	logAndQuitIfError(err)
+
+	b, err := ioutil.ReadFile(fp)
+	logAndQuitIfError(err)
+	translations, err := parseTranslations(b, ft)
+	if err != nil {
+		return []lintIssue{{File: fp, Locale: locale, Message: err.Error()}}
+	}
+
+	issues := []lintIssue{}
+	for _, key := range sortedKeys(translations) {
+		for _, check := range checks {
+			for _, msg := range check(source[key], translations[key], locale) {
+				issues = append(issues, lintIssue{
+					File:    fp,
+					Key:     key,
+					Locale:  locale,
+					Message: msg,
+				})
+			}
+		}
+	}
+
+	return issues
+}
+
+func PrintLintIssues(issues []lintIssue) {
+	sort.SliceStable(issues, func(i, j int) bool {
+		if issues[i].File != issues[j].File {
+			return issues[i].File < issues[j].File
+		}
+		return issues[i].Key < issues[j].Key
+	})
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprint(w, "File\tKey\tLocale\tProblem\n")
+	for _, i := range issues {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", i.File, i.Key, i.Locale, i.Message)
+	}
+	w.Flush()
+}
+
+// checkICUMessage validates the ICU MessageFormat syntax of a translation,
+// and that its plural arguments have the CLDR categories the locale requires
+func checkICUMessage(source, translation, locale string) []string {
+	if !strings.ContainsAny(source+translation, "{}") {
+		return nil
+	}
+
+	args, err := parseICUMessage(translation)
+	if err != nil {
+		return []string{"invalid ICU message: " + err.Error()}
+	}
+
+	msgs := []string{}
+	for _, arg := range args {
+		switch arg.Type {
+		case "plural":
+			msgs = append(msgs, checkPluralSelectors(arg, requiredPluralCategories(locale))...)
+		case "selectordinal":
+			msgs = append(msgs, checkPluralSelectors(arg, requiredOrdinalCategories(locale))...)
+		case "select":
+			if !arg.hasSelector("other") {
+				msgs = append(msgs, fmt.Sprintf("%s {%s} is missing category: other", arg.Type, arg.Name))
+			}
+		}
+	}
+
+	// the source is in the developers' hands, so only compare against it when
+	// it's valid
+	if sourceArgs, err := parseICUMessage(source); err == nil {
+		for _, sa := range sourceArgs {
+			if sa.Type != "plural" && sa.Type != "select" && sa.Type != "selectordinal" {
+				continue
+			}
+			found := false
+			for _, arg := range args {
+				if arg.Name == sa.Name && arg.Type == sa.Type {
+					found = true
+				}
+			}
+			if !found {
+				msgs = append(msgs, fmt.Sprintf("%s {%s} from the source is missing", sa.Type, sa.Name))
+			}
+		}
+	}
+
+	return msgs
+}
+
+// checkPluralSelectors checks a plural or selectordinal argument only uses
+// known categories, and has the categories the locale requires
+func checkPluralSelectors(arg icuArgument, required []string) []string {
+	msgs := []string{}
+	for _, sel := range arg.Selectors {
+		if !isPluralCategory(sel) && !strings.HasPrefix(sel, "=") {
+			msgs = append(msgs, fmt.Sprintf("%s {%s} has unknown category %s", arg.Type, arg.Name, sel))
+		}
+	}
+
+	missing := []string{}
+	for _, cat := range required {
+		if !arg.hasSelector(cat) {
+			missing = append(missing, cat)
+		}
+	}
+	if len(missing) > 0 {
+		msgs = append(msgs, fmt.Sprintf("%s {%s} is missing categories: %s", arg.Type, arg.Name, strings.Join(missing, ", ")))
+	}
+
+	return msgs
+}
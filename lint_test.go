@@ -0,0 +1,55 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCheckICUMessage(t *testing.T) {
+	tests := []struct {
+		source, translation, locale string
+		msgs                        []string
+	}{
+		{"Hello", "Hallo", "de-DE", nil},
+		{"{n, plural, one {# item} other {# items}}", "{n, plural, one {# Teil} other {# Teile}}", "de-DE", []string{}},
+
+		// the categories each language requires
+		{"{n, plural, one {# item} other {# items}}", "{n, plural, one {# plik} few {# pliki} many {# plików} other {# pliku}}", "pl-PL", []string{}},
+		{"{n, plural, one {# item} other {# items}}", "{n, plural, one {# plik} other {# pliku}}", "pl-PL", []string{"plural {n} is missing categories: few, many"}},
+		{"{n, plural, one {# item} other {# items}}", "{n, plural, one {# файл} few {# файла} other {# файла}}", "ru-RU", []string{"plural {n} is missing categories: many"}},
+		{"{n, plural, one {# item} other {# items}}", "{n, plural, other {# 件}}", "ja-JP", []string{}},
+		{"{n, plural, one {# item} other {# items}}", "{n, plural, =0 {keine} one {# Teil} other {# Teile}}", "de-DE", []string{}},
+		{"{n, plural, one {# item} other {# items}}", "{n, plural, single {# Teil} other {# Teile}}", "de-DE", []string{"plural {n} has unknown category single", "plural {n} is missing categories: one"}},
+
+		// ordinals
+		{"{n, selectordinal, one {#st} two {#nd} few {#rd} other {#th}}", "{n, selectordinal, other {#.}}", "de-DE", []string{}},
+		{"{n, selectordinal, one {#st} two {#nd} few {#rd} other {#th}}", "{n, selectordinal, one {#st} other {#th}}", "en-GB", []string{"selectordinal {n} is missing categories: two, few"}},
+		{"{n, selectordinal, one {#st} other {#th}}", "{n, selectordinal, many {#º} other {#º}}", "it-IT", []string{}},
+
+		{"{g, select, female {she} other {they}}", "{g, select, female {sie}}", "de-DE", []string{"select {g} is missing category: other"}},
+		{"{n, plural, one {# item} other {# items}}", "# Teile", "de-DE", []string{"plural {n} from the source is missing"}},
+		{"{name}", "{name", "de-DE", []string{"invalid ICU message: expected ',' but message ended at position 5"}},
+	}
+
+	for _, tt := range tests {
+		msgs := checkICUMessage(tt.source, tt.translation, tt.locale)
+		if !reflect.DeepEqual(msgs, tt.msgs) {
+			t.Errorf("checkICUMessage(%q, %q, %s) = %q, want %q", tt.source, tt.translation, tt.locale, msgs, tt.msgs)
+		}
+	}
+}
+
+func TestRequiredPluralCategories(t *testing.T) {
+	for locale, want := range map[string][]string{
+		"en-US": {"one", "other"},
+		"pl-PL": {"one", "few", "many", "other"},
+		"ru":    {"one", "few", "many", "other"},
+		"ar-EG": {"zero", "one", "two", "few", "many", "other"},
+		"zh-TW": {"other"},
+		"xx-XX": {"other"},
+	} {
+		if got := requiredPluralCategories(locale); !reflect.DeepEqual(got, want) {
+			t.Errorf("requiredPluralCategories(%s) = %v, want %v", locale, got, want)
+		}
+	}
+}
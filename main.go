@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/99designs/api-sdk-go"
@@ -25,20 +27,38 @@ func logAndQuitIfError(err error) {
 }
 
 var cmdBefore = func(c *cli.Context) error {
-	userID := c.GlobalString("userid")
-	apiKey := c.GlobalString("apikey")
-	projectID := c.GlobalString("projectid")
+	return setupClient(c, false)
+}
+
+// setupClient loads the config and creates the client. When multiProject is
+// true credentials aren't required if the config has projects, as each
+// project gets its own client from forEachProject.
+func setupClient(c *cli.Context, multiProject bool) error {
+	clientFlags.userID = c.GlobalString("userid")
+	clientFlags.apiKey = c.GlobalString("apikey")
+	clientFlags.projectID = c.GlobalString("projectid")
+	clientFlags.timeout = c.GlobalInt("timeout")
+	userID, apiKey, projectID := clientFlags.userID, clientFlags.apiKey, clientFlags.projectID
 	configFile := c.GlobalString("configfile")
-	timeout := c.GlobalInt("timeout")
+	projectName := c.GlobalString("project")
+	dryRun = c.GlobalBool("dry-run")
 
 	if configFile == "" {
-		configFile = "smartling.yml"
+		configFile = findConfigFile()
 	}
 
 	var err error
 	ProjectConfig, err = loadConfig(configFile)
 	if err != nil {
 		loadProjectErr = fmt.Errorf("Error loading %s: %s", configFile, err.Error())
+		if err != ErrConfigFileNotExist {
+			return loadProjectErr
+		}
+	}
+
+	if ProjectConfig != nil && projectName != "" {
+		ProjectConfig, err = ProjectConfig.project(projectName)
+		logAndQuitIfError(err)
 	}
 
 	if ProjectConfig != nil {
@@ -53,25 +73,71 @@ var cmdBefore = func(c *cli.Context) error {
 		}
 	}
 
+	// each project's credentials are checked by forEachProject
+	if multiProject && ProjectConfig != nil && len(ProjectConfig.Projects) > 0 {
+		client = newClient(userID, apiKey, projectID)
+		return nil
+	}
+
 	if apiKey == "" {
 		log.Fatalln("ApiKey not specified in --apikey or", configFile)
 	}
 	if projectID == "" {
-		log.Fatalln("ProjectID not specified in --projectid or", configFile)
+		log.Fatalln("ProjectID not specified in --projectid, --project or", configFile)
 	}
 	if userID == "" {
 		log.Fatalln("UserID not specified in --userid or", configFile)
 	}
 
+	client = newClient(userID, apiKey, projectID)
+
+	return nil
+}
+
+// clientFlags are the global flags for the client, which take precedence
+// over the config
+var clientFlags struct {
+	userID, apiKey, projectID string
+	timeout                   int
+}
+
+func newClient(userID, apiKey, projectID string) *FaultTolerantClient {
 	sc := smartling.NewClient(userID, apiKey)
 
-	if timeout != 0 {
-		sc.HTTP.Timeout = (time.Duration(timeout) * time.Second)
+	if clientFlags.timeout != 0 {
+		// the SDK's clients share a default HTTP client, so copy it
+		httpClient := *sc.HTTP
+		httpClient.Timeout = (time.Duration(clientFlags.timeout) * time.Second)
+		sc.HTTP = &httpClient
 	}
 
-	client = &FaultTolerantClient{sc, projectID, 10}
+	return &FaultTolerantClient{sc, projectID, 10}
+}
 
-	return nil
+func firstNonEmpty(ss ...string) string {
+	for _, s := range ss {
+		if s != "" {
+			return s
+		}
+	}
+	return ""
+}
+
+// fileFormat returns the --format of a file, or the format its extension
+// names. The first of the formats is the default.
+func fileFormat(c *cli.Context, filename string, formats ...string) (string, error) {
+	if format := strings.ToLower(c.String("format")); format != "" {
+		if !stringSlice(formats).contains(format) {
+			return "", fmt.Errorf("Unknown --format %s, it must be %s", format, strings.Join(formats, " or "))
+		}
+		return format, nil
+	}
+
+	if ext := strings.ToLower(strings.TrimPrefix(filepath.Ext(filename), ".")); stringSlice(formats).contains(ext) {
+		return ext, nil
+	}
+
+	return formats[0], nil
 }
 
 func main() {
@@ -99,6 +165,10 @@ func main() {
 			Name:   "projectid, p",
 			Usage:  "Smartling Project ID",
 			EnvVar: "SMARTLING_PROJECTID",
+		}, cli.StringFlag{
+			Name:   "project",
+			Usage:  "Name of the project to use from the projects in the config file",
+			EnvVar: "SMARTLING_PROJECT",
 		}, cli.StringFlag{
 			Name:   "configfile,c",
 			Usage:  "Project config file to use",
@@ -108,6 +178,10 @@ func main() {
 			Value:  60,
 			Usage:  "Maximum time in seconds for an API request to take",
 			EnvVar: "SMARTLING_API_TIMEOUT",
+		}, cli.BoolFlag{
+			Name:   "dry-run",
+			Usage:  "Print the API requests and file writes that would be made, without making them",
+			EnvVar: "SMARTLING_DRY_RUN",
 		},
 
 		cli.VersionFlag,
@@ -119,9 +193,19 @@ func main() {
 		PutCommand,
 		RenameCommand,
 		RmCommand,
+		MvCommand,
+		CpCommand,
 		LastmodifiedCommand,
 		LocalesCommand,
+		AuthorizeCommand,
+		UnauthorizeCommand,
+		JobCommand,
+		StringsCommand,
+		GlossaryCommand,
+		TMCommand,
+		ContextCommand,
 		ProjectCommand,
+		ConfigCommand,
 	}
 
 	err := app.Run(os.Args)
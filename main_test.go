@@ -0,0 +1,35 @@
+package main
+
+import (
+	"flag"
+	"testing"
+
+	"github.com/urfave/cli"
+)
+
+func TestFileFormat(t *testing.T) {
+	tests := []struct {
+		format, filename string
+		want             string
+		err              bool
+	}{
+		{"", "glossary.tbx", "tbx", false},
+		{"", "glossary.CSV", "csv", false},
+		{"", "glossary.xml", "tbx", false},
+		{"", "", "tbx", false},
+		{"csv", "glossary.tbx", "csv", false},
+		{"TBX", "glossary.csv", "tbx", false},
+		{"json", "glossary.csv", "", true},
+	}
+
+	for _, tt := range tests {
+		set := flag.NewFlagSet("test", flag.ContinueOnError)
+		set.String("format", tt.format, "")
+		c := cli.NewContext(nil, set, nil)
+
+		got, err := fileFormat(c, tt.filename, "tbx", "csv")
+		if (err != nil) != tt.err || got != tt.want {
+			t.Errorf("fileFormat(--format=%q, %q) = %q, %v, want %q", tt.format, tt.filename, got, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/99designs/api-sdk-go"
+	"github.com/urfave/cli"
+)
+
+// Overrides are translations that replace the downloaded ones when pulling,
+// kept in the overrides directory as a <locale>.json, .yml or .csv file of
+// keys and values for each locale.
+
+var overridesFileTypes = map[string]smartling.FileType{
+	".json": smartling.FileTypeJSON,
+	".yml":  smartling.FileTypeYAML,
+	".yaml": smartling.FileTypeYAML,
+	".csv":  smartling.FileTypeCSV,
+}
+
+var loadedOverrides = struct {
+	sync.Mutex
+	overrides map[string]map[string]string
+}{overrides: map[string]map[string]string{}}
+
+// overridesLocales returns the locales with overrides
+func overridesLocales() []string {
+	if ProjectConfig.Overrides == "" {
+		return nil
+	}
+
+	files, err := ioutil.ReadDir(localRelativeFilePath(ProjectConfig.Overrides))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	logAndQuitIfError(err)
+
+	locales := []string{}
+	for _, f := range files {
+		ext := path.Ext(f.Name())
+		if _, ok := overridesFileTypes[ext]; ok && !f.IsDir() {
+			locales = append(locales, strings.TrimSuffix(f.Name(), ext))
+		}
+	}
+
+	return locales
+}
+
+// localeOverrides returns the overrides for a locale
+func localeOverrides(locale string) map[string]string {
+	loadedOverrides.Lock()
+	defer loadedOverrides.Unlock()
+
+	dir := localRelativeFilePath(ProjectConfig.Overrides)
+	if o, ok := loadedOverrides.overrides[dir+"/"+locale]; ok {
+		return o
+	}
+
+	overrides := map[string]string{}
+	for ext, ft := range overridesFileTypes {
+		f, err := os.Open(filepath.Join(dir, locale+ext))
+		if os.IsNotExist(err) {
+			continue
+		}
+		logAndQuitIfError(err)
+
+		if ft == smartling.FileTypeCSV {
+			kv := readKeyValues(f, "csv")
+			overrides = kv.values
+		} else {
+			b, err := ioutil.ReadAll(f)
+			logAndQuitIfError(err)
+			overrides, err = parseTranslations(b, ft)
+			logAndQuitIfError(err)
+		}
+		f.Close()
+		break
+	}
+
+	loadedOverrides.overrides[dir+"/"+locale] = overrides
+	return overrides
+}
+
+// applyOverrides replaces the translations in a pulled file with any
+// overrides for its keys
+func applyOverrides(projectFilepath, locale, fp string, b []byte) []byte {
+	ft := filetypeForProjectFile(projectFilepath)
+	if ProjectConfig.Overrides == "" || !isParseableFileType(ft) {
+		return b
+	}
+	overrides := localeOverrides(locale)
+	if len(overrides) == 0 {
+		return b
+	}
+
+	tf, err := parseTranslationFile(b, ft)
+	logAndQuitIfError(err)
+
+	changed := false
+	for _, key := range sortedKeys(overrides) {
+		if v, ok := tf.Get(key); ok && v != overrides[key] {
+			tf.Set(key, overrides[key])
+			log.Printf("Overriding %s in %s\n", key, fp)
+			changed = true
+		}
+	}
+	if !changed {
+		return b
+	}
+
+	b, err = tf.Bytes()
+	logAndQuitIfError(err)
+
+	return b
+}
+
+var projectOverridesCommand = cli.Command{
+	Name:  "overrides",
+	Usage: "manage local translation overrides",
+	Subcommands: []cli.Command{
+		projectOverridesStaleCommand,
+	},
+}
+
+var projectOverridesStaleCommand = cli.Command{
+	Name:  "stale",
+	Usage: "lists overrides that now equal the translation in Smartling",
+	Flags: []cli.Flag{
+		prefixFlag,
+	},
+	Action: func(c *cli.Context) {
+		if len(c.Args()) > 0 {
+			log.Println("Wrong number of arguments")
+			log.Fatalln("Usage: stale")
+		}
+
+		forEachProject(func() {
+			prefix := prefixOrGitPrefix(c.String("prefix"))
+			stale := 0
+
+			for _, locale := range overridesLocales() {
+				overrides := localeOverrides(locale)
+				for _, projectFilepath := range ProjectConfig.Files() {
+					ft := filetypeForProjectFile(projectFilepath)
+					if !isParseableFileType(ft) {
+						continue
+					}
+
+					_, b, err := translateProjectFile(projectFilepath, locale, prefix)
+					if err == errNotPushed {
+						log.Println("Skipping", localRelativeFilePath(projectFilepath), "as it hasn't been pushed")
+						continue
+					}
+					logAndQuitIfError(err)
+					translations, err := parseTranslations(b, ft)
+					logAndQuitIfError(err)
+
+					for _, key := range sortedKeys(overrides) {
+						if v, ok := translations[key]; ok && v == overrides[key] {
+							fmt.Printf("%s\t%s\t%s\n", locale, key, localRelativeFilePath(projectFilepath))
+							stale++
+						}
+					}
+				}
+			}
+
+			if stale == 0 {
+				log.Println("No stale overrides")
+			}
+		})
+	},
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"strings"
+)
+
+// CLDR plural categories, in canonical order
+var pluralCategoryOrder = []string{"zero", "one", "two", "few", "many", "other"}
+
+// cldrPluralCategories maps a language to the cardinal plural categories its
+// CLDR rules use for integer and decimal operands. The compact-number "many"
+// category of fr, es, it, pt and ca (e.g. "1 million") is deliberately left
+// out, as translators are not expected to provide it.
+//
+// Derived from CLDR 44 plurals.xml
+var cldrPluralCategories = map[string][]string{}
+
+func init() {
+	rules := map[string]string{
+		"other": "bm bo dz hnj id ig ii ja jbo jv jw kde kea km ko lkt lo ms my nqo osa sah ses sg su th to tpi vi wo yo yue zh",
+
+		"one other": "af am an as asa ast az bal bem bez bg bn brx ca ce cgg chr ckb da de doi dv ee el en eo es et eu fa ff fi fil fo fr fur fy gl gsw gu ha haw hi hu hy ia io is it jgo jmc ka kab kaj kcg kk kkj kl kn ks ksb ku ky lb lg lij mas mgo mk ml mn mr nah nb nd ne nl nn nnh no nr ny nyn om or os pa pap ps pt rm rof rwk saq sc scn sd sdh seh si sn so sq ss ssy st sv sw syr ta te teo ti tig tk tl tn tr ts ug ur uz ve vo vun wa wae xh xog yi zu",
+
+		"zero one other":              "ksh lag lv prg",
+		"one two other":               "he iu naq sat se sma smi smj smn sms",
+		"one few other":               "bs hr ro mo sh sr",
+		"one two few other":           "dsb hsb gd sl",
+		"one few many other":          "be cs lt pl ru sk uk",
+		"one two few many other":      "br ga gv mt",
+		"zero one two few many other": "ar ars cy kw",
+	}
+
+	for categories, languages := range rules {
+		for _, l := range strings.Fields(languages) {
+			cldrPluralCategories[l] = strings.Fields(categories)
+		}
+	}
+}
+
+// requiredPluralCategories returns the plural categories a message for the
+// given locale must provide
+func requiredPluralCategories(locale string) []string {
+	if cc, ok := cldrPluralCategories[localeLanguage(locale)]; ok {
+		return cc
+	}
+
+	// unknown language, the only safe requirement is "other"
+	return []string{"other"}
+}
+
+func isPluralCategory(s string) bool {
+	for _, c := range pluralCategoryOrder {
+		if c == s {
+			return true
+		}
+	}
+	return false
+}
+
+// cldrOrdinalCategories maps a language to the ordinal plural categories its
+// CLDR rules use, as needed by selectordinal, e.g. 1st, 2nd, 3rd and 4th in
+// English.
+//
+// Derived from CLDR 44 ordinals.xml
+var cldrOrdinalCategories = map[string][]string{}
+
+func init() {
+	rules := map[string]string{
+		"other": "af am an ar bg bs ce cs da de dsb el es et eu fa fi fy gl gsw he hr hsb ia id is ja km kn ko ky lt lv ml mn my nb nl no pa pl prg ps pt ru sd sh si sk sl sr sw ta te th tpi tr ur uz yue zh zu",
+
+		"one other":                   "fil fr ga hu hy lo mo ms ne ro sv tl vi",
+		"few other":                   "be tk uk",
+		"many other":                  "it kk lij sc scn vec",
+		"one many other":              "ka sq",
+		"one two many other":          "mk",
+		"one few many other":          "az",
+		"one two few other":           "ca en gd mr",
+		"one two few many other":      "as bn gu hi or",
+		"zero one two few many other": "cy",
+	}
+
+	for categories, languages := range rules {
+		for _, l := range strings.Fields(languages) {
+			cldrOrdinalCategories[l] = strings.Fields(categories)
+		}
+	}
+}
+
+// requiredOrdinalCategories returns the ordinal categories a selectordinal
+// message for the given locale must provide
+func requiredOrdinalCategories(locale string) []string {
+	if cc, ok := cldrOrdinalCategories[localeLanguage(locale)]; ok {
+		return cc
+	}
+
+	return []string{"other"}
+}
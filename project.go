@@ -5,11 +5,14 @@ import (
 	"fmt"
 	"io/ioutil"
 	"log"
+	"os"
 	"path"
 	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
-	"text/template"
+	"time"
 
 	"github.com/99designs/api-sdk-go"
 	"github.com/urfave/cli"
@@ -19,9 +22,9 @@ var ProjectCommand = cli.Command{
 	Name:  "project",
 	Usage: "manage local project files",
 	Before: func(c *cli.Context) error {
-		err := cmdBefore(c)
+		err := setupClient(c, true)
 		if err != nil {
-			return nil
+			return err
 		}
 
 		return loadProjectErr
@@ -31,6 +34,11 @@ var ProjectCommand = cli.Command{
 		projectStatusCommand,
 		projectPullCommand,
 		projectPushCommand,
+		projectLintCommand,
+		projectWaitCommand,
+		projectEstimateCommand,
+		projectAuthorizeCommand,
+		projectOverridesCommand,
 	},
 }
 
@@ -40,30 +48,99 @@ var prefixFlag = cli.StringFlag{
 	EnvVar: "SMARTLING_PREFIX",
 }
 
+// forEachProject runs f for each of the projects in the config, with
+// ProjectConfig and client set up for that project. If the config has no
+// projects, or one was chosen with --project, f is run once.
+func forEachProject(f func()) {
+	if len(ProjectConfig.Projects) == 0 {
+		f()
+		return
+	}
+
+	rootConfig, rootClient := ProjectConfig, client
+	defer func() {
+		ProjectConfig, client = rootConfig, rootClient
+	}()
+
+	for _, name := range rootConfig.projectNames() {
+		pc, err := rootConfig.project(name)
+		logAndQuitIfError(err)
+
+		// flags take precedence over the project's config, as they do for
+		// a single project
+		userID := firstNonEmpty(clientFlags.userID, pc.UserID)
+		apiKey := firstNonEmpty(clientFlags.apiKey, pc.ApiKey)
+		projectID := firstNonEmpty(clientFlags.projectID, pc.ProjectID)
+		if projectID == "" {
+			log.Fatalf("ProjectID not specified in --projectid or for project %s\n", name)
+		}
+		if apiKey == "" || userID == "" {
+			log.Fatalf("ApiKey or UserID not specified in the flags or for project %s\n", name)
+		}
+
+		ProjectConfig = pc
+		client = newClient(userID, apiKey, projectID)
+		client.RetriesOnError = rootClient.RetriesOnError
+		resetRemoteFileList()
+		accountUID, fallbackSourceLocale.locale = "", ""
+
+		log.Println("Project", name)
+		f()
+	}
+}
+
 func fetchRemoteFileList() stringSlice {
 	files := stringSlice{}
-	listFiles, err := client.List(smartling.FilesListRequest{})
+	listFiles, err := client.ListAll(smartling.FilesListRequest{})
 	logAndQuitIfError(err)
 
-	for _, fs := range listFiles.Items {
+	for _, fs := range listFiles {
 		files = append(files, fs.FileURI)
 	}
 
 	return files
 }
 
+// remoteFileList caches the project's remote files, and is kept up to date
+// as files are pushed
 var remoteFileList = stringSlice{}
 var remoteFileListFetched = false
+var remoteFileListMu sync.Mutex
 
 func getRemoteFileList() stringSlice {
+	remoteFileListMu.Lock()
+	defer remoteFileListMu.Unlock()
+
 	if !remoteFileListFetched {
 		remoteFileList = fetchRemoteFileList()
+		remoteFileListFetched = true
 	}
 
-	return remoteFileList
+	return append(stringSlice{}, remoteFileList...)
+}
+
+func resetRemoteFileList() {
+	remoteFileListMu.Lock()
+	defer remoteFileListMu.Unlock()
+
+	remoteFileList, remoteFileListFetched = stringSlice{}, false
+}
+
+// addRemoteFile adds a pushed file to the cached remote files
+func addRemoteFile(remoteFile string) {
+	remoteFileListMu.Lock()
+	defer remoteFileListMu.Unlock()
+
+	if remoteFileListFetched && !remoteFileList.contains(remoteFile) {
+		remoteFileList = append(remoteFileList, remoteFile)
+	}
 }
 
 func fetchLocales() []string {
+	if len(ProjectConfig.Locales) > 0 {
+		return ProjectConfig.Locales
+	}
+
 	ll := []string{}
 	locales, err := client.Locales()
 	logAndQuitIfError(err)
@@ -74,6 +151,13 @@ func fetchLocales() []string {
 	return ll
 }
 
+func sourceLocale() string {
+	pd, err := client.ProjectDetails()
+	logAndQuitIfError(err)
+
+	return pd.SourceLocaleID
+}
+
 var projectFilesCommand = cli.Command{
 	Name:  "files",
 	Usage: "lists the local files",
@@ -83,9 +167,11 @@ var projectFilesCommand = cli.Command{
 			log.Fatalln("Usage: files")
 		}
 
-		for _, projectFilepath := range ProjectConfig.Files() {
-			fmt.Println(projectFilepath)
-		}
+		forEachProject(func() {
+			for _, projectFilepath := range ProjectConfig.Files() {
+				fmt.Println(localRelativeFilePath(projectFilepath))
+			}
+		})
 	},
 }
 
@@ -98,6 +184,26 @@ var projectStatusCommand = cli.Command{
 			Name:  "awaiting-auth",
 			Usage: "Output the number of strings Awaiting Authorization",
 		},
+		cli.IntFlag{
+			Name:  "require-complete",
+			Usage: "Fail unless the project is at least this percent complete",
+		},
+		cli.StringFlag{
+			Name:  "require-locale",
+			Usage: "Fail unless each locale is at least this percent complete e.g. de-DE=100,fr-FR=90",
+		},
+		cli.IntFlag{
+			Name:  "max-awaiting-auth",
+			Usage: "Fail if more than this number of strings are Awaiting Authorization",
+		},
+		cli.BoolFlag{
+			Name:  "by-locale",
+			Usage: "Show a row per locale instead of a row per file",
+		},
+		cli.BoolFlag{
+			Name:  "no-color",
+			Usage: "Don't colour the table",
+		},
 	},
 	Action: func(c *cli.Context) {
 		if len(c.Args()) > 0 {
@@ -105,28 +211,138 @@ var projectStatusCommand = cli.Command{
 			log.Fatalln("Usage: status")
 		}
 
-		prefix := prefixOrGitPrefix(c.String("prefix"))
-		locales := fetchLocales()
-		statuses := GetProjectStatus(prefix, locales)
+		failed := false
+		forEachProject(func() {
+			prefix := prefixOrGitPrefix(c.String("prefix"))
+			locales := fetchLocales()
+			statuses := GetProjectStatus(prefix, locales)
+
+			if c.Bool("awaiting-auth") {
+				fmt.Println(statuses.AwaitingAuthorizationCount())
+			} else {
+				if c.Bool("no-color") {
+					useColour = false
+				}
+				fmt.Print("\n")
+				PrintProjectStatusTable(statuses, locales, c.Bool("by-locale"))
+				fmt.Print("\n")
+				fmt.Printf("Awaiting Authorization: %4d\n", statuses.AwaitingAuthorizationCount())
+				fmt.Printf("Total:                  %4d\n", statuses.TotalStringsCount())
+			}
 
-		if c.Bool("awaiting-auth") {
-			fmt.Println(statuses.AwaitingAuthorizationCount())
-		} else {
-			fmt.Print("\n")
-			PrintProjectStatusTable(statuses, locales)
-			fmt.Print("\n")
-			fmt.Printf("Awaiting Authorization: %4d\n", statuses.AwaitingAuthorizationCount())
-			fmt.Printf("Total:                  %4d\n", statuses.TotalStringsCount())
+			failures := statuses.CheckThresholds(statusThresholds(c), locales)
+			if len(failures) > 0 {
+				log.Println("\nTranslation thresholds not met:")
+				for _, f := range failures {
+					log.Println("  " + f)
+				}
+				failed = true
+			}
+		})
+
+		if failed {
+			os.Exit(1)
 		}
+	},
+}
+
+// statusThresholds returns the thresholds from the config, overridden by any
+// given as flags
+func statusThresholds(c *cli.Context) StatusThresholds {
+	t := ProjectConfig.Status
+
+	if c.IsSet("require-complete") {
+		n := c.Int("require-complete")
+		t.RequireComplete = &n
+	}
 
+	if c.IsSet("require-locale") {
+		rl, err := parseRequireLocale(c.String("require-locale"))
+		logAndQuitIfError(err)
+		t.RequireLocale = rl
+	}
+
+	if c.IsSet("max-awaiting-auth") {
+		n := c.Int("max-awaiting-auth")
+		t.MaxAwaitingAuth = &n
+	}
+
+	return t
+}
+
+// parseRequireLocale parses the locale=percent entries of --require-locale,
+// e.g. de-DE=100,fr-FR=90
+func parseRequireLocale(s string) (map[string]int, error) {
+	rl := map[string]int{}
+	for _, req := range strings.Split(s, ",") {
+		parts := strings.Split(req, "=")
+		if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" {
+			return nil, fmt.Errorf("Invalid --require-locale entry %q, it must be locale=percent e.g. --require-locale=de-DE=100,fr-FR=90", req)
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(parts[1]))
+		if err != nil {
+			return nil, fmt.Errorf("Invalid --require-locale entry %q, %q isn't a whole percentage", req, parts[1])
+		}
+		rl[strings.TrimSpace(parts[0])] = n
+	}
+
+	return rl, nil
+}
+
+var projectAuthorizeCommand = cli.Command{
+	Name:  "authorize",
+	Usage: "authorize the project's remote files for translation",
+	Flags: []cli.Flag{
+		prefixFlag,
+		localeFlag,
+	},
+	Action: func(c *cli.Context) {
+		if len(c.Args()) > 0 {
+			log.Println("Wrong number of arguments")
+			log.Fatalln("Usage: authorize")
+		}
+
+		forEachProject(func() {
+			prefix := prefixOrGitPrefix(c.String("prefix"))
+			locales := localesOrAll(c)
+
+			before := GetProjectStatus(prefix, locales)
+			authorizeRemoteFiles(before.RemoteFiles(), locales)
+			after := GetProjectStatus(prefix, locales)
+
+			fmt.Print("\n")
+			fmt.Printf("Awaiting Authorization: %4d -> %d\n", before.AwaitingAuthorizationCount(), after.AwaitingAuthorizationCount())
+			fmt.Printf("Total:                  %4d\n", after.TotalStringsCount())
+		})
 	},
 }
 
+func authorizeRemoteFiles(remoteFiles, locales []string) {
+	var wg sync.WaitGroup
+	for _, remoteFile := range remoteFiles {
+		wg.Add(1)
+		go func(remoteFile string) {
+			defer wg.Done()
+			logAndQuitIfError(client.Authorize(remoteFile, locales))
+			log.Println("Authorized", remoteFile)
+		}(remoteFile)
+	}
+	wg.Wait()
+}
+
 var projectPullCommand = cli.Command{
 	Name:  "pull",
 	Usage: "translate local project files using Smartling as a translation memory",
 	Flags: []cli.Flag{
 		prefixFlag,
+		cli.StringFlag{
+			Name:  "tmx",
+			Usage: "Also write all the downloaded translations to this TMX (or .csv) file",
+		},
+		cli.BoolFlag{
+			Name:  "print-paths",
+			Usage: "Print where each file would be pulled to, without pulling",
+		},
 	},
 	Action: func(c *cli.Context) {
 		if len(c.Args()) > 0 {
@@ -134,18 +350,53 @@ var projectPullCommand = cli.Command{
 			log.Fatalln("Usage: pull")
 		}
 
-		prefix := prefixOrGitPrefix(c.String("prefix"))
+		if c.Bool("print-paths") {
+			forEachProject(printPullFilePaths)
+			return
+		}
+
+		forEachProject(func() {
+			prefix := prefixOrGitPrefix(c.String("prefix"))
+
+			if c.String("tmx") != "" && pulledTranslations == nil {
+				pulledTranslations = newTranslationMemory(sourceLocale())
+			}
+
+			pullAllProjectFiles(prefix)
+		})
 
-		pullAllProjectFiles(prefix)
+		if pulledTranslations != nil && dryRun {
+			printDryRun("write %s", c.String("tmx"))
+		} else if pulledTranslations != nil {
+			pulledTranslations.write(c.String("tmx"), tmFormat(c, c.String("tmx")))
+			fmt.Println("Wrote", c.String("tmx"))
+		}
 	},
 }
 
+// printPullFilePaths prints each project file and locale with the path it's
+// pulled to
+func printPullFilePaths() {
+	locales := fetchLocales()
+	for _, projectFilepath := range ProjectConfig.Files() {
+		for _, l := range locales {
+			fmt.Printf("%s\t%s\t%s\n", localRelativeFilePath(projectFilepath), l, localPullFilePath(projectFilepath, l))
+		}
+	}
+}
+
+// pulledTranslations collects the translations downloaded by pull when not nil
+var pulledTranslations *translationMemory
+
 func pullAllProjectFiles(prefix string) {
-	locales, err := client.Locales()
-	logAndQuitIfError(err)
+	// check it's valid before pulling anything
+	_ = ProjectConfig.pullMode()
+
+	locales := fetchLocales()
 
 	// do this first to cache result and prevent races in the goroutines
 	_ = getRemoteFileList()
+	prefetchFallbacks(locales, prefix)
 
 	var wg sync.WaitGroup
 	for _, projectFilepath := range ProjectConfig.Files() {
@@ -155,7 +406,7 @@ func pullAllProjectFiles(prefix string) {
 				defer wg.Done()
 
 				pullProjectFile(projectFilepath, locale, prefix)
-			}(l.LocaleID, projectFilepath)
+			}(l, projectFilepath)
 		}
 	}
 	wg.Wait()
@@ -163,16 +414,52 @@ func pullAllProjectFiles(prefix string) {
 
 func pullProjectFile(projectFilepath, locale, prefix string) {
 	hit, b, err := translateProjectFile(projectFilepath, locale, prefix)
+	fp := localPullFilePath(projectFilepath, locale)
+	if err == errNotPushed {
+		printDryRun("write %s once %s is pushed and translated", fp, projectFilepath)
+		return
+	}
 	logAndQuitIfError(err)
 
-	fp := localPullFilePath(projectFilepath, locale)
 	cached := ""
 	if hit {
 		cached = "(using cache)"
 	}
-	err = ioutil.WriteFile(fp, b, 0644)
+	downloaded := b
+	b = applyFallbacks(projectFilepath, locale, prefix, b)
+	b = mergePulledFile(projectFilepath, fp, b)
+	b = applyOverrides(projectFilepath, locale, fp, b)
+	b = formatPulledFile(projectFilepath, fp, b)
+
+	if existing, err := ioutil.ReadFile(fp); err == nil && bytes.Equal(existing, b) {
+		fmt.Println("Unchanged", fp, cached)
+	} else if dryRun {
+		printDryRun("write %s %s", fp, cached)
+	} else {
+		err = ioutil.WriteFile(fp, b, 0644)
+		logAndQuitIfError(err)
+		fmt.Println("Wrote", fp, cached)
+	}
+
+	if pulledTranslations != nil {
+		// only what Smartling returned, not local overrides or fallbacks
+		remoteFile := findIdenticalRemoteFileOrPush(projectFilepath, prefix)
+		addToTranslationMemory(pulledTranslations, projectFilepath, remoteFile, locale, downloaded)
+	}
+}
+
+func addToTranslationMemory(tm *translationMemory, projectFilepath, remoteFile, locale string, b []byte) {
+	ft := filetypeForProjectFile(projectFilepath)
+	if !isParseableFileType(ft) {
+		return
+	}
+
+	source, err := parseTranslations(readFile(projectFilepath), ft)
 	logAndQuitIfError(err)
-	fmt.Println("Wrote", fp, cached)
+	translations, err := parseTranslations(b, ft)
+	logAndQuitIfError(err)
+
+	tm.add(remoteFile, source, translations, locale)
 }
 
 func cleanPrefix(s string) string {
@@ -201,6 +488,19 @@ var projectPushCommand = cli.Command{
 	Usage: "upload local project files that contain untranslated strings",
 	Flags: []cli.Flag{
 		prefixFlag,
+		cli.StringFlag{
+			Name:  "job",
+			Usage: "Name of a job to add the files to, it's created if it doesn't exist",
+		},
+		dueFlag,
+		cli.BoolFlag{
+			Name:  "watch",
+			Usage: "Keep running, and push files again when they change",
+		},
+		cli.StringFlag{
+			Name:  "watch-pull",
+			Usage: "When watching, pull pseudo or pending translations of the changed files",
+		},
 	},
 	Action: func(c *cli.Context) {
 		if len(c.Args()) > 0 {
@@ -208,12 +508,44 @@ var projectPushCommand = cli.Command{
 			log.Fatalln("Usage: push")
 		}
 
-		prefix := prefixOrGitPrefix(c.String("prefix"))
+		retrievalType := smartling.RetrievalType(c.String("watch-pull"))
+		switch retrievalType {
+		case smartling.RetrieveDefault, smartling.RetrievePseudo, smartling.RetrievePending:
+		default:
+			log.Fatalln("--watch-pull must be pseudo or pending")
+		}
+		if c.Bool("watch") && len(ProjectConfig.Projects) > 0 {
+			log.Fatalln("--watch needs a single project, choose one with --project")
+		}
+
+		forEachProject(func() {
+			prefix := prefixOrGitPrefix(c.String("prefix"))
 
-		pushAllProjectFiles(prefix)
+			remoteFiles := pushAllProjectFiles(prefix)
+
+			if c.String("job") != "" {
+				addFilesToJob(c.String("job"), parseDueDate(c.String("due")), remoteFiles)
+			}
+
+			if c.Bool("watch") {
+				watchProjectFiles(prefix, retrievalType)
+			}
+		})
 	},
 }
 
+func addFilesToJob(name string, due time.Time, remoteFiles []string) {
+	locales := fetchLocales()
+	j := findOrCreateJob(name, due, locales)
+
+	for _, remoteFile := range remoteFiles {
+		logAndQuitIfError(client.AddFileToJob(j.TranslationJobUID, remoteFile, locales))
+		log.Println("Added", remoteFile, "to job", j.JobName)
+	}
+
+	fmt.Println(j.TranslationJobUID)
+}
+
 // if prefix is empty, don't append the hash also
 func projectFileRemoteName(projectFilepath, prefix string) string {
 	remoteFile := projectFilepath
@@ -225,25 +557,45 @@ func projectFileRemoteName(projectFilepath, prefix string) string {
 }
 
 func readFile(projectFilepath string) []byte {
-	f, err := ioutil.ReadFile(projectFilepath)
+	f, err := ioutil.ReadFile(localRelativeFilePath(projectFilepath))
 	logAndQuitIfError(err)
 	return f
 }
 
 func pushProjectFile(projectFilepath, prefix string) string {
+	remoteFile, err := uploadProjectFile(projectFilepath, prefix)
+	logAndQuitIfError(err)
+
+	return remoteFile
+}
+
+// uploadProjectFile pushes a project file, returning any error rather than
+// quitting
+func uploadProjectFile(projectFilepath, prefix string) (string, error) {
 	remoteFile := projectFileRemoteName(projectFilepath, prefix)
 
+	f, err := ioutil.ReadFile(localRelativeFilePath(projectFilepath))
+	if err != nil {
+		return "", err
+	}
+
 	req := &smartling.FileUploadRequest{
 		FileURIRequest: smartling.FileURIRequest{FileURI: remoteFile},
 		FileType:       filetypeForProjectFile(projectFilepath),
-		File:           readFile(projectFilepath),
+		File:           f,
 	}
 	req.Smartling.Directives = ProjectConfig.ParserConfig
-	_, err := client.Upload(req)
-	logAndQuitIfError(err)
+	r, err := client.Upload(req)
+	if err != nil {
+		return "", err
+	}
+	if dryRun {
+		return remoteFile, nil
+	}
+	addRemoteFile(remoteFile)
 
-	log.Println("Uploaded", remoteFile)
-	return remoteFile
+	log.Printf("Uploaded %s (%d strings, %d words)\n", remoteFile, r.StringCount, r.WordCount)
+	return remoteFile, nil
 }
 
 func pushProjectFileIfNotExists(projectFilepath, prefix string) (string, bool) {
@@ -257,8 +609,11 @@ func pushProjectFileIfNotExists(projectFilepath, prefix string) (string, bool) {
 	return pushProjectFile(projectFilepath, prefix), true
 }
 
-func pushAllProjectFiles(prefix string) {
-	pushedFilesCount := 0
+// pushAllProjectFiles returns the remote names of all the project files
+func pushAllProjectFiles(prefix string) []string {
+	var mu sync.Mutex
+	pushed := map[string]string{}
+	remoteFiles := []string{}
 
 	// do this first to cache result and prevent races in the goroutines
 	_ = getRemoteFileList()
@@ -268,17 +623,26 @@ func pushAllProjectFiles(prefix string) {
 		wg.Add(1)
 		go func(projectFilepath string) {
 			defer wg.Done()
-			_, pushed := pushProjectFileIfNotExists(projectFilepath, prefix)
-			if pushed {
-				pushedFilesCount++
+			remoteFile, ok := pushProjectFileIfNotExists(projectFilepath, prefix)
+
+			mu.Lock()
+			defer mu.Unlock()
+			remoteFiles = append(remoteFiles, remoteFile)
+			if ok {
+				pushed[projectFilepath] = remoteFile
 			}
 		}(projectFilepath)
 	}
 	wg.Wait()
 
-	if pushedFilesCount == 0 {
+	if len(pushed) == 0 {
 		fmt.Println("Nothing to do")
 	}
+
+	uploadProjectContext(pushed)
+
+	sort.Strings(remoteFiles)
+	return remoteFiles
 }
 
 func filetypeForProjectFile(projectFilepath string) smartling.FileType {
@@ -293,48 +657,8 @@ func filetypeForProjectFile(projectFilepath string) smartling.FileType {
 	return ft
 }
 
-type FilenameParts struct {
-	Path           string
-	Base           string
-	Dir            string
-	Ext            string
-	PathWithoutExt string
-	Locale         string
-}
-
 func localRelativeFilePath(remotepath string) string {
 	fp, err := filepath.Rel(".", path.Join(ProjectConfig.path, remotepath))
 	logAndQuitIfError(err)
 	return fp
 }
-
-func localPullFilePath(p, locale string) string {
-	parts := FilenameParts{
-		Path:   p,
-		Dir:    path.Dir(p),
-		Base:   path.Base(p),
-		Ext:    path.Ext(p),
-		Locale: locale,
-	}
-
-	dt := defaultPullDestination
-	if dt != "" {
-		dt = ProjectConfig.PullFilePath
-	}
-
-	out := bytes.NewBufferString("")
-	tmpl := template.New("name")
-	tmpl.Funcs(template.FuncMap{
-		"TrimSuffix": strings.TrimSuffix,
-		"Truncate": func(s string, n int) string {
-			return s[:n]
-		},
-	})
-	_, err := tmpl.Parse(dt)
-	logAndQuitIfError(err)
-
-	err = tmpl.Execute(out, parts)
-	logAndQuitIfError(err)
-
-	return localRelativeFilePath(out.String())
-}
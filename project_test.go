@@ -0,0 +1,72 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestForEachProjectUsesEachProjectsCredentials(t *testing.T) {
+	f := newFakeSmartling(t)
+	f.use(t, &Config{
+		UserID: "root-user",
+		ApiKey: "root-key",
+		Projects: map[string]*Config{
+			"web": {ProjectID: "web-id", UserID: "web-user", ApiKey: "web-key"},
+			"app": {ProjectID: "app-id"},
+		},
+	})
+
+	type credentials struct{ userID, apiKey, projectID string }
+	run := func() map[string]credentials {
+		got := map[string]credentials{}
+		forEachProject(func() {
+			got[ProjectConfig.name] = credentials{client.Credentials.UserID, client.Credentials.Secret, client.ProjectID}
+		})
+		return got
+	}
+
+	want := map[string]credentials{
+		"web": {"web-user", "web-key", "web-id"},
+		"app": {"root-user", "root-key", "app-id"},
+	}
+	if got := run(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	clientFlags.projectID, clientFlags.apiKey = "flag-id", "flag-key"
+	defer func() { clientFlags.projectID, clientFlags.apiKey = "", "" }()
+	want = map[string]credentials{
+		"web": {"web-user", "flag-key", "flag-id"},
+		"app": {"root-user", "flag-key", "flag-id"},
+	}
+	if got := run(); !reflect.DeepEqual(got, want) {
+		t.Errorf("with flags got %v, want %v", got, want)
+	}
+}
+
+func TestPushedFilesAreAddedToTheRemoteFileList(t *testing.T) {
+	chdir(t, t.TempDir())
+	writeTestFile(t, "en.json", `{"a": "A"}`)
+
+	f := newFakeProject(t, &Config{path: ".", FileGlobs: []string{"en.json"}, PullFilePath: "{{.Locale}}.json", Locales: []string{"de-DE", "fr-FR", "es-ES"}})
+	f.handle("POST", "/files-api/v2/projects/project/file", func(r fakeRequest) interface{} {
+		return map[string]interface{}{"stringCount": 1}
+	})
+	for _, locale := range ProjectConfig.Locales {
+		f.handle("GET", "/files-api/v2/projects/project/locales/"+locale+"/file", func(r fakeRequest) interface{} {
+			return []byte(`{"a": "translated"}`)
+		})
+	}
+
+	captureStdout(t, func() { pullAllProjectFiles("/test") })
+
+	if n := len(f.Requests("POST", "/files-api/v2/projects/project/file")); n != 1 {
+		t.Errorf("got %d uploads, want the file pushed once for all locales", n)
+	}
+	if files := getRemoteFileList(); len(files) != 1 {
+		t.Errorf("got remote files %v, want the pushed file", files)
+	}
+	if n := len(f.Requests("GET", "/files-api/v2/projects/project/files/list")); n != 1 {
+		t.Errorf("listed the files %d times, want once", n)
+	}
+}
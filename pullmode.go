@@ -0,0 +1,74 @@
+package main
+
+import (
+	"io/ioutil"
+	"log"
+	"os"
+)
+
+// The pull_mode config sets how pulled translations are written
+const (
+	// pullModeOverwrite replaces the local file with the downloaded one
+	pullModeOverwrite = "overwrite"
+
+	// pullModeMerge updates the strings of an existing local file, keeping
+	// its order, comments and any keys that aren't in the source
+	pullModeMerge = "merge"
+
+	// pullModeMissingOnly only adds the strings missing from an existing
+	// local file
+	pullModeMissingOnly = "missing-only"
+)
+
+func (c *Config) pullMode() string {
+	switch c.PullMode {
+	case "":
+		return pullModeOverwrite
+	case pullModeOverwrite, pullModeMerge, pullModeMissingOnly:
+		return c.PullMode
+	}
+
+	log.Fatalf("pull_mode must be %s, %s or %s\n", pullModeOverwrite, pullModeMerge, pullModeMissingOnly)
+	return ""
+}
+
+// mergePulledFile merges the downloaded translations into the existing local
+// file at fp, depending on the pull mode. The existing file is returned
+// unchanged if there's nothing to merge, so it isn't reformatted.
+func mergePulledFile(projectFilepath, fp string, b []byte) []byte {
+	mode := ProjectConfig.pullMode()
+	ft := filetypeForProjectFile(projectFilepath)
+	if mode == pullModeOverwrite || !isParseableFileType(ft) {
+		return b
+	}
+
+	existing, err := ioutil.ReadFile(fp)
+	if os.IsNotExist(err) {
+		return b
+	}
+	logAndQuitIfError(err)
+
+	local, err := parseTranslationFile(existing, ft)
+	logAndQuitIfError(err)
+	downloaded, err := parseTranslationFile(b, ft)
+	logAndQuitIfError(err)
+
+	changed := false
+	for _, key := range downloaded.Keys() {
+		v, _ := downloaded.Get(key)
+		current, ok := local.Get(key)
+		if ok && (current == v || mode == pullModeMissingOnly) {
+			continue
+		}
+		local.Set(key, v)
+		changed = true
+	}
+	if !changed {
+		return existing
+	}
+
+	b, err = local.Bytes()
+	logAndQuitIfError(err)
+
+	return b
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"testing"
+)
+
+func pullModeProject(t *testing.T, mode string) {
+	chdir(t, t.TempDir())
+	pc := ProjectConfig
+	ProjectConfig = &Config{path: ".", PullMode: mode}
+	t.Cleanup(func() { ProjectConfig = pc })
+}
+
+func TestMergePulledFile(t *testing.T) {
+	local := "# greetings\nhello: Hallo # informal\nbye: Tschüss\nlocal_only: Nur lokal\n"
+	downloaded := "hello: Hallo!\nbye: Tschüss\nnew: Neu\n"
+
+	tests := []struct {
+		mode, want string
+	}{
+		{pullModeOverwrite, downloaded},
+		{pullModeMerge, "# greetings\nhello: Hallo! # informal\nbye: Tschüss\nlocal_only: Nur lokal\nnew: Neu\n"},
+		{pullModeMissingOnly, "# greetings\nhello: Hallo # informal\nbye: Tschüss\nlocal_only: Nur lokal\nnew: Neu\n"},
+	}
+
+	for _, tt := range tests {
+		pullModeProject(t, tt.mode)
+		writeTestFile(t, "de.yml", local)
+
+		if got := string(mergePulledFile("en.yml", "de.yml", []byte(downloaded))); got != tt.want {
+			t.Errorf("%s: got:\n%s\nwant:\n%s", tt.mode, got, tt.want)
+		}
+	}
+}
+
+func TestMergePulledFileWithoutALocalFile(t *testing.T) {
+	pullModeProject(t, pullModeMerge)
+
+	downloaded := `{"hello": "Hallo"}`
+	if got := string(mergePulledFile("en.json", "de.json", []byte(downloaded))); got != downloaded {
+		t.Errorf("got %s, want the downloaded file", got)
+	}
+}
+
+func TestMergePulledFileKeepsUnchangedFilesAsTheyAre(t *testing.T) {
+	for _, mode := range []string{pullModeMerge, pullModeMissingOnly} {
+		pullModeProject(t, mode)
+		local := "{\"hello\":   \"Hallo\",\n\t\"bye\": \"Tschüss\"}"
+		writeTestFile(t, "de.json", local)
+
+		if got := string(mergePulledFile("en.json", "de.json", []byte(`{"hello": "Hallo", "bye": "Tschüss"}`))); got != local {
+			t.Errorf("%s: got %q, want the local file byte for byte", mode, got)
+		}
+		if mode == pullModeMissingOnly {
+			if got := string(mergePulledFile("en.json", "de.json", []byte(`{"hello": "Hi"}`))); got != local {
+				t.Errorf("%s: got %q, want the local file byte for byte", mode, got)
+			}
+		}
+	}
+}
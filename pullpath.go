@@ -0,0 +1,156 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+	"sync"
+	"text/template"
+	"unicode"
+
+	"github.com/99designs/api-sdk-go"
+)
+
+// FilenameParts is the data available to the pull_file_path template
+type FilenameParts struct {
+	Path           string
+	Base           string
+	Dir            string
+	Ext            string
+	PathWithoutExt string
+	BaseWithoutExt string
+	Locale         string
+	Language       string
+	Region         string
+	Project        string
+	FileType       string
+	Branch         string
+}
+
+var pullPathFuncs = template.FuncMap{
+	"TrimSuffix": strings.TrimSuffix,
+	"TrimPrefix": strings.TrimPrefix,
+	"Truncate": func(s string, n int) string {
+		r := []rune(s)
+		if n < 0 || n >= len(r) {
+			return s
+		}
+		return string(r[:n])
+	},
+	"lower": strings.ToLower,
+	"upper": strings.ToUpper,
+	"replace": func(old, new, s string) string {
+		return strings.Replace(s, old, new, -1)
+	},
+	"regexReplace": func(re, repl, s string) (string, error) {
+		r, err := regexp.Compile(re)
+		if err != nil {
+			return "", err
+		}
+		return r.ReplaceAllString(s, repl), nil
+	},
+	"default": func(def, s string) string {
+		if s == "" {
+			return def
+		}
+		return s
+	},
+	// posixLocale converts de-DE to de_DE
+	"posixLocale": func(locale string) string {
+		return strings.Replace(locale, "-", "_", -1)
+	},
+	"androidLocale": androidLocale,
+}
+
+// androidLocale converts de-DE to de-rDE, as used in values-de-rDE. Tags with
+// more than a language and a two letter region, e.g. zh-Hant-TW or es-419,
+// use the BCP 47 form b+zh+Hant+TW.
+func androidLocale(locale string) string {
+	subtags := strings.FieldsFunc(locale, func(r rune) bool { return r == '-' || r == '_' })
+	switch {
+	case len(subtags) == 1:
+		return subtags[0]
+	case len(subtags) == 2 && len(subtags[1]) == 2 && !unicode.IsDigit(rune(subtags[1][0])):
+		return subtags[0] + "-r" + strings.ToUpper(subtags[1])
+	default:
+		return "b+" + strings.Join(subtags, "+")
+	}
+}
+
+// localeRegion returns the region of a locale, e.g. DE for de-DE
+func localeRegion(locale string) string {
+	if i := strings.IndexAny(locale, "-_"); i != -1 {
+		return locale[i+1:]
+	}
+	return ""
+}
+
+var cachedGitBranch struct {
+	sync.Once
+	branch string
+}
+
+func filenameParts(p, locale string) FilenameParts {
+	cachedGitBranch.Do(func() {
+		cachedGitBranch.branch = gitBranch()
+	})
+
+	ext := path.Ext(p)
+	ft := smartling.GetFileTypeByExtension(ext)
+	if ft == "" {
+		ft = ProjectConfig.FileType
+	}
+
+	return FilenameParts{
+		Path:           p,
+		Dir:            path.Dir(p),
+		Base:           path.Base(p),
+		Ext:            ext,
+		PathWithoutExt: strings.TrimSuffix(p, ext),
+		BaseWithoutExt: strings.TrimSuffix(path.Base(p), ext),
+		Locale:         locale,
+		Language:       localeLanguage(locale),
+		Region:         localeRegion(locale),
+		Project:        ProjectConfig.name,
+		FileType:       string(ft),
+		Branch:         cachedGitBranch.branch,
+	}
+}
+
+// pullFilePath renders the pull_file_path template for a project file,
+// checking the result is usable
+func pullFilePath(p, locale string) (string, error) {
+	dt := defaultPullDestination
+	if ProjectConfig.PullFilePath != "" {
+		dt = ProjectConfig.PullFilePath
+	}
+
+	tmpl, err := template.New("pull_file_path").Funcs(pullPathFuncs).Parse(dt)
+	if err != nil {
+		return "", fmt.Errorf("Invalid pull_file_path: %s", err.Error())
+	}
+
+	out := bytes.NewBufferString("")
+	if err := tmpl.Execute(out, filenameParts(p, locale)); err != nil {
+		return "", fmt.Errorf("Invalid pull_file_path: %s", err.Error())
+	}
+
+	fp := strings.TrimSpace(out.String())
+	if fp == "" {
+		return "", fmt.Errorf("pull_file_path is empty for %s in %s", p, locale)
+	}
+	if path.Clean(fp) == path.Clean(p) {
+		return "", fmt.Errorf("pull_file_path would overwrite %s with its %s translation", p, locale)
+	}
+
+	return fp, nil
+}
+
+func localPullFilePath(p, locale string) string {
+	fp, err := pullFilePath(p, locale)
+	logAndQuitIfError(err)
+
+	return localRelativeFilePath(fp)
+}
@@ -0,0 +1,20 @@
+package main
+
+import "testing"
+
+func TestAndroidLocale(t *testing.T) {
+	tests := map[string]string{
+		"de":         "de",
+		"de-DE":      "de-rDE",
+		"pt_BR":      "pt-rBR",
+		"es-419":     "b+es+419",
+		"zh-Hant":    "b+zh+Hant",
+		"zh-Hant-TW": "b+zh+Hant+TW",
+		"sr-Latn-RS": "b+sr+Latn+RS",
+	}
+	for locale, want := range tests {
+		if got := androidLocale(locale); got != want {
+			t.Errorf("androidLocale(%q) = %q, want %q", locale, got, want)
+		}
+	}
+}
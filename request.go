@@ -0,0 +1,170 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/99designs/api-sdk-go"
+)
+
+// The SDK only covers the Files API, so requests to the other Smartling APIs
+// are made with these helpers. They use the SDK's credentials and HTTP client
+// and decode the same response envelope.
+
+const (
+	contentTypeJSON = "application/json"
+	contentTypeForm = "application/x-www-form-urlencoded"
+)
+
+// readOnlyEndpointSuffixes are the endpoints that are POSTed to but don't
+// change anything, so they're still made in a dry run
+var readOnlyEndpointSuffixes = []string{
+	"/search", // glossaries and glossary entries
+}
+
+func isReadOnlyRequest(method, endpoint string) bool {
+	if method == "GET" {
+		return true
+	}
+	for _, suffix := range readOnlyEndpointSuffixes {
+		if method == "POST" && strings.HasSuffix(endpoint, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *FaultTolerantClient) requestJSON(method, endpoint string, params url.Values, payload, result interface{}) error {
+	var body []byte
+	if payload != nil {
+		var err error
+		body, err = json.Marshal(payload)
+		if err != nil {
+			return err
+		}
+	}
+
+	return c.request(method, endpoint, params, body, contentTypeJSON, result)
+}
+
+func (c *FaultTolerantClient) request(method, endpoint string, params url.Values, body []byte, contentType string, result interface{}) error {
+	if dryRun && !isReadOnlyRequest(method, endpoint) {
+		printDryRunRequest(method, endpoint, params, body, contentType)
+		return nil
+	}
+
+	resp, b, err := c.do(method, endpoint, params, body, contentType)
+	if err != nil {
+		return err
+	}
+
+	var envelope struct {
+		Response struct {
+			Code   string
+			Data   json.RawMessage
+			Errors []struct {
+				Key     string
+				Message string
+			}
+		}
+	}
+	_ = json.Unmarshal(b, &envelope)
+
+	apiErr := smartling.APIError{
+		Code:     envelope.Response.Code,
+		URL:      endpoint,
+		Params:   params,
+		Payload:  body,
+		Response: b,
+		Headers:  &resp.Header,
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized:
+		return smartling.NotAuthorizedError{}
+	case resp.StatusCode == http.StatusNotFound:
+		return smartling.NotFoundError{}
+	case strings.ToLower(envelope.Response.Code) == "validation_error":
+		return smartling.ValidationError{Errors: envelope.Response.Errors}
+	case resp.StatusCode >= 300:
+		apiErr.Cause = fmt.Errorf("API call returned unexpected HTTP code: %d", resp.StatusCode)
+		return apiErr
+	}
+
+	if result == nil || len(envelope.Response.Data) == 0 {
+		return nil
+	}
+
+	if err := json.Unmarshal(envelope.Response.Data, result); err != nil {
+		apiErr.Cause = fmt.Errorf("unable to decode API response data: %s", err)
+		return apiErr
+	}
+
+	return nil
+}
+
+// download makes a GET request for a file, such as a translation, that's
+// returned as is rather than in the response envelope
+func (c *FaultTolerantClient) download(endpoint string, params url.Values) ([]byte, error) {
+	resp, b, err := c.do("GET", endpoint, params, nil, "")
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized:
+		return nil, smartling.NotAuthorizedError{}
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, smartling.NotFoundError{}
+	case resp.StatusCode >= 300:
+		return nil, smartling.APIError{
+			Cause:    fmt.Errorf("API call returned unexpected HTTP code: %d", resp.StatusCode),
+			URL:      endpoint,
+			Params:   params,
+			Response: b,
+			Headers:  &resp.Header,
+		}
+	}
+
+	return b, nil
+}
+
+// do makes an authenticated request and reads the response
+func (c *FaultTolerantClient) do(method, endpoint string, params url.Values, body []byte, contentType string) (*http.Response, []byte, error) {
+	if err := c.Client.Authenticate(); err != nil {
+		return nil, nil, fmt.Errorf("unable to authenticate: %s", err)
+	}
+
+	u := c.Client.BaseURL + endpoint
+	if len(params) > 0 {
+		u += "?" + params.Encode()
+	}
+
+	req, err := http.NewRequest(method, u, bytes.NewReader(body))
+	if err != nil {
+		return nil, nil, err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	req.Header.Set("User-Agent", c.Client.UserAgent)
+	req.Header.Set("Authorization", "Bearer "+c.Client.Credentials.AccessToken.Value)
+
+	resp, err := c.Client.HTTP.Do(req)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to perform HTTP request: %s", err)
+	}
+	defer resp.Body.Close()
+
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return resp, b, nil
+}
@@ -3,31 +3,232 @@ package main
 import (
 	"fmt"
 	"os"
+	"sort"
+	"strings"
 	"sync"
 	"text/tabwriter"
 
 	"github.com/99designs/api-sdk-go"
 )
 
-func mustStatus(remotefile string) smartling.FileStatus {
-	fs, err := client.Status(remotefile)
-	logAndQuitIfError(err)
+// LocaleStatus counts the strings and words in each state for a locale, in
+// one file or summed across files
+type LocaleStatus struct {
+	Locale            string
+	TotalStrings      int
+	TotalWords        int
+	AuthorizedStrings int
+	AuthorizedWords   int
+	CompletedStrings  int
+	CompletedWords    int
+	ExcludedStrings   int
+	ExcludedWords     int
+
+	// HasStatus is false when no file has a status for the locale, e.g. as
+	// it was added to the project after the file was uploaded
+	HasStatus bool
+}
+
+func newLocaleStatus(fs smartling.FileStatus, locale string) LocaleStatus {
+	fst, err := fs.GetFileStatusTranslation(locale)
+	if err != nil {
+		return LocaleStatus{Locale: locale}
+	}
 
-	return *fs
+	return LocaleStatus{
+		Locale:            locale,
+		HasStatus:         true,
+		TotalStrings:      fs.TotalStringCount,
+		TotalWords:        fs.TotalWordCount,
+		AuthorizedStrings: fst.AuthorizedStringCount,
+		AuthorizedWords:   fst.AuthorizedWordCount,
+		CompletedStrings:  fst.CompletedStringCount,
+		CompletedWords:    fst.CompletedWordCount,
+		ExcludedStrings:   fst.ExcludedStringCount,
+		ExcludedWords:     fst.ExcludedWordCount,
+	}
+}
+
+func (ls *LocaleStatus) add(o LocaleStatus) {
+	if !o.HasStatus {
+		return
+	}
+	ls.HasStatus = true
+	ls.TotalStrings += o.TotalStrings
+	ls.TotalWords += o.TotalWords
+	ls.AuthorizedStrings += o.AuthorizedStrings
+	ls.AuthorizedWords += o.AuthorizedWords
+	ls.CompletedStrings += o.CompletedStrings
+	ls.CompletedWords += o.CompletedWords
+	ls.ExcludedStrings += o.ExcludedStrings
+	ls.ExcludedWords += o.ExcludedWords
 }
 
+func (ls LocaleStatus) AwaitingAuthorizationStrings() int {
+	return ls.TotalStrings - ls.AuthorizedStrings - ls.CompletedStrings - ls.ExcludedStrings
+}
+
+func (ls LocaleStatus) AwaitingAuthorizationWords() int {
+	return ls.TotalWords - ls.AuthorizedWords - ls.CompletedWords - ls.ExcludedWords
+}
+
+// CompletedPercent returns the percentage of translatable strings that are
+// completed, or 0 if there's no status
+func (ls LocaleStatus) CompletedPercent() float64 {
+	if !ls.HasStatus {
+		return 0
+	}
+	return percent(ls.CompletedStrings, ls.TotalStrings-ls.ExcludedStrings)
+}
+
+// ProjectStatus is the status of each of the project's files, keyed by the
+// remote file URI, along with the local file each was uploaded from. It's safe
+// for concurrent use.
 type ProjectStatus struct {
-	sync.RWMutex
-	statuses map[string]smartling.FileStatus
+	mu         sync.RWMutex
+	statuses   map[string]smartling.FileStatus
+	localFiles map[string]string
 }
 
 func New() *ProjectStatus {
 	return &ProjectStatus{
-		statuses: make(map[string]smartling.FileStatus),
+		statuses:   make(map[string]smartling.FileStatus),
+		localFiles: make(map[string]string),
+	}
+}
+
+// Set records the status of a local file and the remote file it was
+// uploaded as
+func (ps *ProjectStatus) Set(projectFilepath, remoteFile string, fs smartling.FileStatus) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	ps.localFiles[projectFilepath] = remoteFile
+	ps.statuses[remoteFile] = fs
+}
+
+// LocalFiles returns the local project files, sorted
+func (ps *ProjectStatus) LocalFiles() []string {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+
+	return sortedKeys(ps.localFiles)
+}
+
+// RemoteFiles returns the remote file URIs, sorted
+func (ps *ProjectStatus) RemoteFiles() []string {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+
+	ff := []string{}
+	for f := range ps.statuses {
+		ff = append(ff, f)
+	}
+	sort.Strings(ff)
+
+	return ff
+}
+
+// RemoteFile returns the remote file a local file was uploaded as
+func (ps *ProjectStatus) RemoteFile(projectFilepath string) (string, bool) {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+
+	remoteFile, ok := ps.localFiles[projectFilepath]
+	return remoteFile, ok
+}
+
+// LocalFile returns the local file a remote file was uploaded from
+func (ps *ProjectStatus) LocalFile(remoteFile string) (string, bool) {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+
+	for projectFilepath, r := range ps.localFiles {
+		if r == remoteFile {
+			return projectFilepath, true
+		}
+	}
+	return "", false
+}
+
+// Each calls f with the status of each local file, in order of the local
+// file paths
+func (ps *ProjectStatus) Each(f func(projectFilepath string, fs smartling.FileStatus)) {
+	for _, projectFilepath := range ps.LocalFiles() {
+		f(projectFilepath, ps.FileStatus(projectFilepath))
+	}
+}
+
+// FileStatus returns the status of a local file
+func (ps *ProjectStatus) FileStatus(projectFilepath string) smartling.FileStatus {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+
+	return ps.statuses[ps.localFiles[projectFilepath]]
+}
+
+// FileLocaleStatus returns the status of a local file in a locale
+func (ps *ProjectStatus) FileLocaleStatus(projectFilepath, locale string) LocaleStatus {
+	return newLocaleStatus(ps.FileStatus(projectFilepath), locale)
+}
+
+// FileLocalesStatus returns the status of a local file summed across the
+// locales
+func (ps *ProjectStatus) FileLocalesStatus(projectFilepath string, locales []string) LocaleStatus {
+	fs := ps.FileStatus(projectFilepath)
+	total := LocaleStatus{}
+	for _, locale := range locales {
+		total.add(newLocaleStatus(fs, locale))
+	}
+
+	return total
+}
+
+// LocaleStatus returns the status of a locale summed across the files
+func (ps *ProjectStatus) LocaleStatus(locale string) LocaleStatus {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+
+	total := LocaleStatus{Locale: locale}
+	for _, fs := range ps.statuses {
+		total.add(newLocaleStatus(fs, locale))
 	}
+
+	return total
+}
+
+// LocalesStatus returns the status summed across the files and locales
+func (ps *ProjectStatus) LocalesStatus(locales []string) LocaleStatus {
+	total := LocaleStatus{}
+	for _, locale := range locales {
+		total.add(ps.LocaleStatus(locale))
+	}
+
+	return total
+}
+
+// FileLocaleCompletedPercent returns the percentage of a local file's
+// translatable strings that are completed for a locale
+func (ps *ProjectStatus) FileLocaleCompletedPercent(projectFilepath, locale string) float64 {
+	return ps.FileLocaleStatus(projectFilepath, locale).CompletedPercent()
+}
+
+// FileCompletedPercent returns the percentage of a local file's translatable
+// strings that are completed across the locales
+func (ps *ProjectStatus) FileCompletedPercent(projectFilepath string, locales []string) float64 {
+	return ps.FileLocalesStatus(projectFilepath, locales).CompletedPercent()
+}
+
+// FileAwaitingAuthorizationCount returns the number of a local file's strings
+// awaiting authorization across the locales
+func (ps *ProjectStatus) FileAwaitingAuthorizationCount(projectFilepath string, locales []string) int {
+	return ps.FileLocalesStatus(projectFilepath, locales).AwaitingAuthorizationStrings()
 }
 
 func (ps *ProjectStatus) AwaitingAuthorizationCount() int {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+
 	c := 0
 	for _, s := range ps.statuses {
 		c += s.AwaitingAuthorizationStringCount()
@@ -36,6 +237,9 @@ func (ps *ProjectStatus) AwaitingAuthorizationCount() int {
 }
 
 func (ps *ProjectStatus) TotalStringsCount() int {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+
 	c := 0
 	for _, s := range ps.statuses {
 		c += s.TotalStringCount
@@ -44,52 +248,206 @@ func (ps *ProjectStatus) TotalStringsCount() int {
 	return c
 }
 
+func (ps *ProjectStatus) TotalWordsCount() int {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+
+	c := 0
+	for _, s := range ps.statuses {
+		c += s.TotalWordCount
+	}
+
+	return c
+}
+
+// LocaleCompletedPercent returns the percentage of translatable strings that
+// are completed for a locale
+func (ps *ProjectStatus) LocaleCompletedPercent(locale string) float64 {
+	return ps.LocaleStatus(locale).CompletedPercent()
+}
+
+// CompletedPercent returns the percentage of translatable strings that are
+// completed across the locales
+func (ps *ProjectStatus) CompletedPercent(locales []string) float64 {
+	return ps.LocalesStatus(locales).CompletedPercent()
+}
+
+func percent(n, total int) float64 {
+	if total <= 0 {
+		return 100
+	}
+	return float64(n) * 100 / float64(total)
+}
+
+// CheckThresholds returns a description of each threshold that isn't met
+func (ps *ProjectStatus) CheckThresholds(t StatusThresholds, locales []string) []string {
+	failures := []string{}
+
+	if t.RequireComplete != nil {
+		if p := ps.CompletedPercent(locales); p < float64(*t.RequireComplete) {
+			failures = append(failures, fmt.Sprintf("Project is %.1f%% complete, %d%% required", p, *t.RequireComplete))
+		}
+	}
+
+	for _, locale := range sortedIntKeys(t.RequireLocale) {
+		required := t.RequireLocale[locale]
+		if !stringSlice(locales).contains(locale) {
+			failures = append(failures, fmt.Sprintf("require_locale has unknown locale %s, the project's locales are %s", locale, strings.Join(locales, ", ")))
+			continue
+		}
+		if p := ps.LocaleCompletedPercent(locale); p < float64(required) {
+			failures = append(failures, fmt.Sprintf("%s is %.1f%% complete, %d%% required", locale, p, required))
+		}
+	}
+
+	if t.MaxAwaitingAuth != nil {
+		if c := ps.AwaitingAuthorizationCount(); c > *t.MaxAwaitingAuth {
+			failures = append(failures, fmt.Sprintf("%d strings are awaiting authorization, at most %d allowed", c, *t.MaxAwaitingAuth))
+		}
+	}
+
+	return failures
+}
+
 func GetProjectStatus(prefix string, locales []string) *ProjectStatus {
-	var wg sync.WaitGroup
-	statuses := New()
+	statuses, err := fetchProjectStatus(projectRemoteFiles(prefix))
+	logAndQuitIfError(err)
+
+	return statuses
+}
 
+// projectRemoteFiles returns the remote file of each project file, keyed by
+// the project file, pushing any that haven't been uploaded
+func projectRemoteFiles(prefix string) map[string]string {
+	remoteFiles := map[string]string{}
 	for _, projectFilepath := range ProjectConfig.Files() {
-		remoteFilePath := findIdenticalRemoteFileOrPush(projectFilepath, prefix)
+		remoteFiles[projectFilepath] = findIdenticalRemoteFileOrPush(projectFilepath, prefix)
+	}
 
+	return remoteFiles
+}
+
+// fetchProjectStatus gets the status of remote files without pushing
+// anything, so it's safe to poll
+func fetchProjectStatus(remoteFiles map[string]string) (*ProjectStatus, error) {
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var firstErr error
+	statuses := New()
+
+	for projectFilepath, remoteFile := range remoteFiles {
 		wg.Add(1)
-		go func(remoteFile string) {
+		go func(projectFilepath, remoteFile string) {
 			defer wg.Done()
-			fs := mustStatus(remoteFile)
-			statuses.statuses[remoteFile] = fs
-		}(remoteFilePath)
+			fs, err := client.Status(remoteFile)
+			if err != nil {
+				mu.Lock()
+				if firstErr == nil {
+					firstErr = fmt.Errorf("%s: %s", remoteFile, err.Error())
+				}
+				mu.Unlock()
+				return
+			}
+			statuses.Set(projectFilepath, remoteFile, *fs)
+		}(projectFilepath, remoteFile)
 	}
 	wg.Wait()
 
-	return statuses
+	return statuses, firstErr
 }
 
-func PrintProjectStatusTable(ps *ProjectStatus, locales []string) {
-	// Format in columns
-	w := tabwriter.NewWriter(os.Stdout, 0, 0, 1, ' ', 0)
+// ANSI colours, all the same length so columns line up
+const (
+	colourDefault = "\x1b[39m"
+	colourRed     = "\x1b[31m"
+	colourYellow  = "\x1b[33m"
+	colourGreen   = "\x1b[32m"
+	colourReset   = "\x1b[0m"
+)
 
-	fmt.Fprint(w, "Awaiting\t  In Progress -> Completed\n")
-	fmt.Fprint(w, "    Auth\t\n")
-	for _, locale := range locales {
-		fmt.Fprint(w, "\t  ", locale)
+// useColour is whether to colour the status table
+var useColour = isTerminal(os.Stdout) && os.Getenv("NO_COLOR") == ""
+
+func isTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+func colourise(colour, s string) string {
+	if !useColour {
+		return s
+	}
+	return colour + s + colourReset
+}
+
+// percentCell formats a percentage, coloured by how complete it is
+// statusCell shows how complete a status is, or n.a. if there's no status
+func statusCell(ls LocaleStatus) string {
+	if !ls.HasStatus {
+		return "n.a."
 	}
-	fmt.Fprint(w, "\n")
+	return percentCell(ls.CompletedPercent())
+}
+
+func percentCell(p float64) string {
+	colour := colourRed
+	if p >= 100 {
+		colour = colourGreen
+	} else if p >= 50 {
+		colour = colourYellow
+	}
+
+	return colourise(colour, fmt.Sprintf("%.0f%%", p))
+}
+
+// PrintProjectStatusTable prints how complete each local file is in each
+// locale, with a row per file, or a row per locale if byLocale is set
+func PrintProjectStatusTable(ps *ProjectStatus, locales []string, byLocale bool) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	files := ps.LocalFiles()
+
+	if byLocale {
+		fmt.Fprint(w, "Locale\t")
+		for _, f := range files {
+			fmt.Fprint(w, colourise(colourDefault, f), "\t")
+		}
+		fmt.Fprint(w, colourise(colourDefault, "Total"), "\t\n")
 
-	for projectFilepath, _ := range ps.statuses {
-		aa := false
 		for _, locale := range locales {
-			status := ps.statuses[projectFilepath]
-			fst, err := status.GetFileStatusTranslation(locale)
-			if err != nil {
-				logAndQuitIfError(err)
+			fmt.Fprint(w, locale, "\t")
+			for _, f := range files {
+				fmt.Fprint(w, statusCell(ps.FileLocaleStatus(f, locale)), "\t")
 			}
-			if !aa {
-				fmt.Fprintf(w, "%7d", fst.AwaitingAuthorizationStringCount(status.TotalStringCount))
-				aa = true
+			fmt.Fprint(w, statusCell(ps.LocaleStatus(locale)), "\t\n")
+		}
+
+		fmt.Fprint(w, "Total\t")
+		for _, f := range files {
+			fmt.Fprint(w, statusCell(ps.FileLocalesStatus(f, locales)), "\t")
+		}
+		fmt.Fprint(w, statusCell(ps.LocalesStatus(locales)), "\t\n")
+	} else {
+		// the file is last so the paths don't need aligning right
+		fmt.Fprint(w, "Awaiting Auth\t")
+		for _, locale := range locales {
+			fmt.Fprint(w, colourise(colourDefault, locale), "\t")
+		}
+		fmt.Fprint(w, colourise(colourDefault, "Total"), "\t  File\n")
+
+		for _, f := range files {
+			fmt.Fprintf(w, "%d\t", ps.FileAwaitingAuthorizationCount(f, locales))
+			for _, locale := range locales {
+				fmt.Fprint(w, statusCell(ps.FileLocaleStatus(f, locale)), "\t")
 			}
+			fmt.Fprint(w, statusCell(ps.FileLocalesStatus(f, locales)), "\t  ", f, "\n")
+		}
 
-			fmt.Fprintf(w, "\t%3d->%-3d", fst.AuthorizedStringCount, fst.CompletedStringCount)
+		fmt.Fprintf(w, "%d\t", ps.LocalesStatus(locales).AwaitingAuthorizationStrings())
+		for _, locale := range locales {
+			fmt.Fprint(w, statusCell(ps.LocaleStatus(locale)), "\t")
 		}
-		fmt.Fprint(w, "\t", projectFilepath, "\n")
+		fmt.Fprint(w, statusCell(ps.LocalesStatus(locales)), "\t  Total\n")
 	}
+
 	w.Flush()
 }
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/99designs/api-sdk-go"
+)
+
+func TestProjectStatusConcurrentUse(t *testing.T) {
+	ps := New()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			fs := smartling.FileStatus{
+				TotalStringCount: 2,
+				Items:            []smartling.FileStatusTranslation{{LocaleID: "de-DE", CompletedStringCount: 1}},
+			}
+			ps.Set(fmt.Sprintf("file%d.json", i), fmt.Sprintf("/file%d.json", i), fs)
+		}(i)
+		go func() {
+			defer wg.Done()
+			ps.LocaleStatus("de-DE")
+		}()
+	}
+	wg.Wait()
+
+	ls := ps.LocaleStatus("de-DE")
+	if ls.TotalStrings != 20 || ls.CompletedStrings != 10 {
+		t.Errorf("got %d of %d strings completed, want 10 of 20", ls.CompletedStrings, ls.TotalStrings)
+	}
+	if n := len(ps.RemoteFiles()); n != 10 {
+		t.Errorf("got %d remote files, want 10", n)
+	}
+}
+
+func thresholdsStatus() *ProjectStatus {
+	ps := New()
+	ps.Set("en.json", "/en.json", smartling.FileStatus{
+		TotalStringCount: 10,
+		Items: []smartling.FileStatusTranslation{
+			{LocaleID: "de-DE", CompletedStringCount: 10},
+			{LocaleID: "fr-FR", CompletedStringCount: 5, AuthorizedStringCount: 3},
+		},
+	})
+	return ps
+}
+
+func TestCheckThresholds(t *testing.T) {
+	n := func(n int) *int { return &n }
+	locales := []string{"de-DE", "fr-FR"}
+
+	tests := []struct {
+		thresholds StatusThresholds
+		failures   []string
+	}{
+		{StatusThresholds{}, []string{}},
+		{StatusThresholds{RequireComplete: n(75)}, []string{}},
+		{StatusThresholds{RequireComplete: n(80)}, []string{"Project is 75.0% complete, 80% required"}},
+		{StatusThresholds{RequireLocale: map[string]int{"de-DE": 100, "fr-FR": 50}}, []string{}},
+		{StatusThresholds{RequireLocale: map[string]int{"de-DE": 100, "fr-FR": 60}}, []string{"fr-FR is 50.0% complete, 60% required"}},
+		{StatusThresholds{RequireLocale: map[string]int{"es-ES": 10}}, []string{"require_locale has unknown locale es-ES, the project's locales are de-DE, fr-FR"}},
+		{StatusThresholds{MaxAwaitingAuth: n(2)}, []string{}},
+		{StatusThresholds{MaxAwaitingAuth: n(1)}, []string{"2 strings are awaiting authorization, at most 1 allowed"}},
+	}
+
+	for _, tt := range tests {
+		if got := thresholdsStatus().CheckThresholds(tt.thresholds, locales); !reflect.DeepEqual(got, tt.failures) {
+			t.Errorf("CheckThresholds(%+v) = %q, want %q", tt.thresholds, got, tt.failures)
+		}
+	}
+}
+
+func TestParseRequireLocale(t *testing.T) {
+	rl, err := parseRequireLocale("de-DE=100, fr-FR=90")
+	if err != nil || !reflect.DeepEqual(rl, map[string]int{"de-DE": 100, "fr-FR": 90}) {
+		t.Errorf("got %v, %v", rl, err)
+	}
+
+	for _, s := range []string{"de-DE", "de-DE=100,fr-FR", "de-DE=all", "=100"} {
+		if _, err := parseRequireLocale(s); err == nil {
+			t.Errorf("parseRequireLocale(%q) didn't fail", s)
+		}
+	}
+	if _, err := parseRequireLocale("de-DE=100,fr-FR=ninety"); err == nil || !strings.Contains(err.Error(), `"fr-FR=ninety"`) {
+		t.Errorf("expected the bad entry to be reported, got %v", err)
+	}
+}
+
+// TestProjectStatusExitCode runs project status in a subprocess, as it exits
+// when a threshold isn't met
+func TestProjectStatusExitCode(t *testing.T) {
+	if args := os.Getenv("SMARTLING_TEST_STATUS_ARGS"); args != "" {
+		chdir(t, t.TempDir())
+		writeTestFile(t, "en.json", `{"a": "A"}`)
+		f := newFakeProject(t, &Config{path: ".", FileGlobs: []string{"en.json"}, Locales: []string{"de-DE", "fr-FR"}})
+		f.handle("POST", "/files-api/v2/projects/project/file", func(r fakeRequest) interface{} {
+			return map[string]interface{}{"stringCount": 10}
+		})
+		f.handle("GET", "/files-api/v2/projects/project/file/status", func(r fakeRequest) interface{} {
+			return thresholdsStatus().FileStatus("en.json")
+		})
+		runCommand(t, projectStatusCommand, append([]string{"--prefix", "/test"}, strings.Fields(args)...)...)
+		return
+	}
+
+	tests := []struct {
+		args string
+		code int
+		out  string
+	}{
+		{"--require-complete=75", 0, "PASS"},
+		{"--require-locale=de-DE=100,fr-FR=50", 0, "PASS"},
+		{"--require-locale=fr-FR=60", 1, "fr-FR is 50.0% complete, 60% required"},
+		{"--require-complete=80 --max-awaiting-auth=5", 1, "Project is 75.0% complete, 80% required"},
+		{"--require-locale=fr-FR=high", 1, `Invalid --require-locale entry "fr-FR=high"`},
+	}
+	for _, tt := range tests {
+		cmd := exec.Command(os.Args[0], "-test.run=^TestProjectStatusExitCode$")
+		cmd.Env = append(os.Environ(), "SMARTLING_TEST_STATUS_ARGS="+tt.args)
+		out, err := cmd.CombinedOutput()
+
+		code := 0
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			code = exitErr.ExitCode()
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		if code != tt.code || !strings.Contains(string(out), tt.out) {
+			t.Errorf("status %s exited with %d, want %d and %q:\n%s", tt.args, code, tt.code, tt.out, out)
+		}
+	}
+}
+
+func TestPrintProjectStatusTable(t *testing.T) {
+	colour := useColour
+	useColour = false
+	defer func() { useColour = colour }()
+
+	ps := New()
+	ps.Set("a.json", "/a.json", smartling.FileStatus{
+		TotalStringCount: 10,
+		Items: []smartling.FileStatusTranslation{
+			{LocaleID: "de-DE", CompletedStringCount: 5, AuthorizedStringCount: 2},
+			{LocaleID: "fr-FR", CompletedStringCount: 10},
+			{LocaleID: "es-ES"},
+		},
+	})
+	// added before fr-FR was a target locale
+	ps.Set("b.json", "/b.json", smartling.FileStatus{
+		TotalStringCount: 4,
+		Items:            []smartling.FileStatusTranslation{{LocaleID: "de-DE", CompletedStringCount: 4}},
+	})
+
+	out := captureStdout(t, func() { PrintProjectStatusTable(ps, []string{"de-DE", "fr-FR"}, false) })
+
+	// es-ES isn't shown, so its 10 strings awaiting authorization aren't
+	// counted
+	want := []string{
+		"Awaiting Auth de-DE fr-FR Total File",
+		"3 50% 100% 75% a.json",
+		"0 100% n.a. 100% b.json",
+		"3 64% 100% 79% Total",
+	}
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	for i := range lines {
+		lines[i] = strings.Join(strings.Fields(lines[i]), " ")
+	}
+	if !reflect.DeepEqual(lines, want) {
+		t.Errorf("got:\n%s", out)
+	}
+}
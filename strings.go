@@ -0,0 +1,362 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/99designs/api-sdk-go"
+	"github.com/urfave/cli"
+)
+
+// the Strings API accepts at most this many strings or hashcodes per request
+const stringsBatchSize = 100
+
+type sourceString struct {
+	StringText string `json:"stringText"`
+	Variant    string `json:"variant,omitempty"`
+	Namespace  string `json:"namespace,omitempty"`
+}
+
+type createdString struct {
+	Variant    string
+	StringText string
+	Hashcode   string
+}
+
+type stringTranslation struct {
+	Hashcode       string
+	TargetLocaleID string `json:"targetLocaleId"`
+	Translations   []struct {
+		Translation string
+		PluralForm  string
+	}
+}
+
+func (c *FaultTolerantClient) stringsEndpoint(path string) string {
+	return fmt.Sprintf("/strings-api/v2/projects/%s%s", c.ProjectID, path)
+}
+
+// CreateStrings uploads strings to the project and returns their hashcodes
+func (c *FaultTolerantClient) CreateStrings(ss []sourceString) (created []createdString, err error) {
+	for i := 0; i < len(ss); i += stringsBatchSize {
+		batch := ss[i:minInt(i+stringsBatchSize, len(ss))]
+
+		var r struct {
+			Items      []createdString
+			ProcessUID string `json:"processUid"`
+		}
+		c.execWithRetry(func() error {
+			err = c.requestJSON("POST", c.stringsEndpoint(""), nil, map[string]interface{}{
+				"strings": batch,
+			}, &r)
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		// a 202 response means the strings are still being created
+		if r.ProcessUID != "" {
+			if err = c.waitForStringsProcess(r.ProcessUID); err != nil {
+				return nil, err
+			}
+		}
+
+		created = append(created, r.Items...)
+	}
+
+	return created, nil
+}
+
+// how often and for how long to poll a Strings API process
+var (
+	stringsProcessInterval = 2 * time.Second
+	stringsProcessTimeout  = 5 * time.Minute
+)
+
+// waitForStringsProcess polls a Strings API process until it's completed
+func (c *FaultTolerantClient) waitForStringsProcess(processUID string) error {
+	deadline := time.Now().Add(stringsProcessTimeout)
+
+	for {
+		var p struct {
+			ProcessState string
+		}
+		var err error
+		c.execWithRetry(func() error {
+			err = c.requestJSON("GET", c.stringsEndpoint("/processes/"+processUID), nil, nil, &p)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+
+		switch p.ProcessState {
+		case "COMPLETED":
+			return nil
+		case "FAILED":
+			return fmt.Errorf("Creating strings failed, process %s", processUID)
+		}
+
+		if time.Now().After(deadline) {
+			return fmt.Errorf("Timed out waiting for process %s to create the strings", processUID)
+		}
+		log.Println("Waiting for Smartling to create the strings")
+		time.Sleep(stringsProcessInterval)
+	}
+}
+
+// StringTranslations returns the translations of strings for a locale,
+// keyed by hashcode
+func (c *FaultTolerantClient) StringTranslations(locale string, hashcodes []string) (translations map[string]string, err error) {
+	translations = map[string]string{}
+
+	for i := 0; i < len(hashcodes); i += stringsBatchSize {
+		params := url.Values{
+			"targetLocaleId": {locale},
+			"hashcodes[]":    hashcodes[i:minInt(i+stringsBatchSize, len(hashcodes))],
+		}
+
+		var r struct {
+			Items []stringTranslation
+		}
+		c.execWithRetry(func() error {
+			err = c.requestJSON("GET", c.stringsEndpoint("/translations"), params, nil, &r)
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, st := range r.Items {
+			if len(st.Translations) > 0 {
+				translations[st.Hashcode] = st.Translations[0].Translation
+			}
+		}
+	}
+
+	return translations, nil
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// keyValues is an ordered list of key/value pairs
+type keyValues struct {
+	keys   []string
+	values map[string]string
+}
+
+func (kv *keyValues) set(k, v string) {
+	if kv.values == nil {
+		kv.values = map[string]string{}
+	}
+	if _, ok := kv.values[k]; !ok {
+		kv.keys = append(kv.keys, k)
+	}
+	kv.values[k] = v
+}
+
+func keyValuesFormat(c *cli.Context, filename string) string {
+	format, err := fileFormat(c, filename, "csv", "json")
+	logAndQuitIfError(err)
+
+	return format
+}
+
+// readKeyValues reads key/value pairs from a CSV file with key,value rows or
+// a JSON object. A CSV row with a single column is used as both key and value.
+func readKeyValues(r io.Reader, format string) keyValues {
+	kv := keyValues{}
+
+	switch format {
+	case "json":
+		b, err := ioutil.ReadAll(r)
+		logAndQuitIfError(err)
+		values, err := parseTranslations(b, smartling.FileTypeJSON)
+		logAndQuitIfError(err)
+		for _, k := range sortedKeys(values) {
+			kv.set(k, values[k])
+		}
+	default:
+		cr := csv.NewReader(r)
+		cr.FieldsPerRecord = -1
+		rows, err := cr.ReadAll()
+		logAndQuitIfError(err)
+		for _, row := range rows {
+			if len(row) == 1 {
+				kv.set(row[0], row[0])
+			} else {
+				kv.set(row[0], row[1])
+			}
+		}
+	}
+
+	return kv
+}
+
+func writeKeyValues(w io.Writer, format string, kv keyValues) {
+	switch format {
+	case "json":
+		b, err := json.MarshalIndent(kv.values, "", "  ")
+		logAndQuitIfError(err)
+		fmt.Fprintln(w, string(b))
+	default:
+		cw := csv.NewWriter(w)
+		for _, k := range kv.keys {
+			logAndQuitIfError(cw.Write([]string{k, kv.values[k]}))
+		}
+		cw.Flush()
+		logAndQuitIfError(cw.Error())
+	}
+}
+
+func openInput(c *cli.Context) io.ReadCloser {
+	if c.Args().Get(0) == "" || c.Args().Get(0) == "-" {
+		return os.Stdin
+	}
+
+	f, err := os.Open(c.Args().Get(0))
+	logAndQuitIfError(err)
+
+	return f
+}
+
+var formatFlag = cli.StringFlag{
+	Name:  "format",
+	Usage: "Format of the key/value pairs, csv or json. Defaults to the file extension, or csv",
+}
+
+var StringsCommand = cli.Command{
+	Name:   "strings",
+	Usage:  "manage individual strings",
+	Before: cmdBefore,
+	Subcommands: []cli.Command{
+		stringsPushCommand,
+		stringsPullCommand,
+	},
+}
+
+var stringsPushCommand = cli.Command{
+	Name:        "push",
+	Usage:       "uploads key/value pairs as strings, and outputs the key/hashcode pairs",
+	Description: "push [--format csv|json] [--namespace <namespace>] [<file>]",
+	Flags: []cli.Flag{
+		formatFlag,
+		cli.StringFlag{
+			Name:  "namespace",
+			Usage: "Namespace for the strings",
+		},
+	},
+	Action: func(c *cli.Context) {
+		if len(c.Args()) > 1 {
+			log.Println("Wrong number of arguments")
+			log.Fatalln("Usage: push [--format csv|json] [--namespace <namespace>] [<file>]")
+		}
+
+		format := keyValuesFormat(c, c.Args().Get(0))
+		in := openInput(c)
+		defer in.Close()
+		kv := readKeyValues(in, format)
+
+		ss := []sourceString{}
+		for _, k := range kv.keys {
+			ss = append(ss, sourceString{
+				StringText: kv.values[k],
+				Variant:    k,
+				Namespace:  c.String("namespace"),
+			})
+		}
+
+		created, err := client.CreateStrings(ss)
+		logAndQuitIfError(err)
+
+		hashcodes := keyValues{}
+		for _, s := range created {
+			hashcodes.set(s.Variant, s.Hashcode)
+		}
+		writeKeyValues(os.Stdout, format, hashcodes)
+	},
+}
+
+var stringsPullCommand = cli.Command{
+	Name:        "pull",
+	Usage:       "reads key/hashcode pairs and outputs the translated key/value pairs",
+	Description: "pull [--format csv|json] [--locale <locale>]... [<file>]",
+	Flags: []cli.Flag{
+		formatFlag,
+		localeFlag,
+	},
+	Action: func(c *cli.Context) {
+		if len(c.Args()) > 1 {
+			log.Println("Wrong number of arguments")
+			log.Fatalln("Usage: pull [--format csv|json] [--locale <locale>]... [<file>]")
+		}
+
+		format := keyValuesFormat(c, c.Args().Get(0))
+		in := openInput(c)
+		defer in.Close()
+		hashcodes := readKeyValues(in, format)
+
+		locales := localesOrAll(c)
+		if len(locales) == 1 {
+			writeKeyValues(os.Stdout, format, pullStrings(locales[0], hashcodes))
+			return
+		}
+
+		// key/value pairs for each locale, as CSV rows of key,locale,value or
+		// a JSON object keyed by locale
+		all := map[string]map[string]string{}
+		rows := [][]string{}
+		for _, locale := range locales {
+			kv := pullStrings(locale, hashcodes)
+			all[locale] = kv.values
+			for _, k := range kv.keys {
+				rows = append(rows, []string{k, locale, kv.values[k]})
+			}
+		}
+
+		if format == "json" {
+			b, err := json.MarshalIndent(all, "", "  ")
+			logAndQuitIfError(err)
+			fmt.Println(string(b))
+		} else {
+			logAndQuitIfError(csv.NewWriter(os.Stdout).WriteAll(rows))
+		}
+	},
+}
+
+func pullStrings(locale string, hashcodes keyValues) keyValues {
+	translations, err := client.StringTranslations(locale, hashcodesOf(hashcodes))
+	logAndQuitIfError(err)
+
+	kv := keyValues{values: map[string]string{}}
+	for _, k := range hashcodes.keys {
+		if t, ok := translations[hashcodes.values[k]]; ok {
+			kv.set(k, t)
+		} else {
+			log.Println("No", locale, "translation for", k)
+		}
+	}
+
+	return kv
+}
+
+func hashcodesOf(kv keyValues) []string {
+	hh := []string{}
+	for _, k := range kv.keys {
+		hh = append(hh, kv.values[k])
+	}
+	return hh
+}
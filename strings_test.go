@@ -0,0 +1,55 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCreateStringsWaitsForProcess(t *testing.T) {
+	f := newFakeSmartling(t)
+	f.use(t, &Config{})
+	stringsProcessInterval = time.Millisecond
+	defer func() { stringsProcessInterval = 2 * time.Second }()
+
+	f.handle("POST", "/strings-api/v2/projects/project", func(r fakeRequest) interface{} {
+		return map[string]interface{}{
+			"processUid": "process1",
+			"items":      []map[string]string{{"variant": "hello", "hashcode": "abc"}},
+		}
+	})
+	states := []string{"OPEN", "OPEN", "COMPLETED"}
+	f.handle("GET", "/strings-api/v2/projects/project/processes/process1", func(r fakeRequest) interface{} {
+		state := states[0]
+		if len(states) > 1 {
+			states = states[1:]
+		}
+		return map[string]string{"processState": state}
+	})
+
+	created, err := client.CreateStrings([]sourceString{{StringText: "Hello", Variant: "hello"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(f.Requests("GET", "/strings-api/v2/projects/project/processes/process1")); n != 3 {
+		t.Errorf("polled the process %d times, want 3", n)
+	}
+	if len(created) != 1 || created[0].Hashcode != "abc" {
+		t.Errorf("got %+v, want the created string", created)
+	}
+}
+
+func TestCreateStringsFailedProcess(t *testing.T) {
+	f := newFakeSmartling(t)
+	f.use(t, &Config{})
+
+	f.handle("POST", "/strings-api/v2/projects/project", func(r fakeRequest) interface{} {
+		return map[string]interface{}{"processUid": "process1"}
+	})
+	f.handle("GET", "/strings-api/v2/projects/project/processes/process1", func(r fakeRequest) interface{} {
+		return map[string]string{"processState": "FAILED"}
+	})
+
+	if _, err := client.CreateStrings([]sourceString{{StringText: "Hello"}}); err == nil {
+		t.Error("expected an error when the process fails")
+	}
+}
@@ -1,5 +1,9 @@
 package main
 
+import (
+	"sort"
+)
+
 type stringSlice []string
 
 func (ss stringSlice) contains(s string) bool {
@@ -10,3 +14,23 @@ func (ss stringSlice) contains(s string) bool {
 	}
 	return false
 }
+
+func sortedKeys(kv map[string]string) []string {
+	keys := []string{}
+	for k := range kv {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+func sortedIntKeys(m map[string]int) []string {
+	keys := []string{}
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
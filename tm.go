@@ -0,0 +1,406 @@
+package main
+
+import (
+	"crypto/sha1"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/99designs/api-sdk-go"
+	"github.com/urfave/cli"
+)
+
+// tmUnit is a source string and its translations
+type tmUnit struct {
+	ID      string
+	Source  string
+	Targets map[string]string
+}
+
+// translationMemory collects translation units, and is safe for concurrent
+// use
+type translationMemory struct {
+	sync.Mutex
+	SourceLocale string
+	units        map[string]*tmUnit
+}
+
+func newTranslationMemory(sourceLocale string) *translationMemory {
+	return &translationMemory{
+		SourceLocale: sourceLocale,
+		units:        map[string]*tmUnit{},
+	}
+}
+
+// tmUnitID identifies a string by the remote file it's in and its key, so
+// the TMX written by project pull and tm export use the same ids
+func tmUnitID(fileURI, key string) string {
+	return fileURI + "#" + key
+}
+
+// add adds the translations of a remote file's strings, skipping those that
+// are missing or empty. Translations identical to their source are kept, as
+// names and cognates are translated that way.
+func (tm *translationMemory) add(fileURI string, source, translations map[string]string, locale string) {
+	tm.Lock()
+	defer tm.Unlock()
+
+	for key, s := range source {
+		t, ok := translations[key]
+		if !ok || t == "" || s == "" {
+			continue
+		}
+
+		id := tmUnitID(fileURI, key)
+		u, ok := tm.units[id]
+		if !ok {
+			u = &tmUnit{ID: id, Source: s, Targets: map[string]string{}}
+			tm.units[id] = u
+		}
+		u.Targets[locale] = t
+	}
+}
+
+func (tm *translationMemory) sortedUnits() []*tmUnit {
+	uu := []*tmUnit{}
+	for _, u := range tm.units {
+		uu = append(uu, u)
+	}
+	sort.Slice(uu, func(i, j int) bool { return uu[i].ID < uu[j].ID })
+
+	return uu
+}
+
+func (tm *translationMemory) locales() []string {
+	seen := map[string]int{}
+	for _, u := range tm.units {
+		for l := range u.Targets {
+			seen[l]++
+		}
+	}
+
+	return sortedIntKeys(seen)
+}
+
+// A TMX 1.4 document
+
+type tmxDocument struct {
+	XMLName xml.Name  `xml:"tmx"`
+	Version string    `xml:"version,attr"`
+	Header  tmxHeader `xml:"header"`
+	Units   []tmxTU   `xml:"body>tu"`
+}
+
+type tmxHeader struct {
+	CreationTool        string `xml:"creationtool,attr"`
+	CreationToolVersion string `xml:"creationtoolversion,attr"`
+	SegType             string `xml:"segtype,attr"`
+	OTMF                string `xml:"o-tmf,attr"`
+	AdminLang           string `xml:"adminlang,attr"`
+	SrcLang             string `xml:"srclang,attr"`
+	DataType            string `xml:"datatype,attr"`
+}
+
+type tmxTU struct {
+	TUID string   `xml:"tuid,attr,omitempty"`
+	TUVs []tmxTUV `xml:"tuv"`
+}
+
+type tmxTUV struct {
+	Lang string `xml:"http://www.w3.org/XML/1998/namespace lang,attr"`
+	Seg  string `xml:"seg"`
+}
+
+func (tm *translationMemory) writeTMX(w io.Writer) {
+	doc := tmxDocument{
+		Version: "1.4",
+		Header: tmxHeader{
+			CreationTool:        "smartling",
+			CreationToolVersion: Version,
+			SegType:             "block",
+			OTMF:                "smartling",
+			AdminLang:           tm.SourceLocale,
+			SrcLang:             tm.SourceLocale,
+			DataType:            "plaintext",
+		},
+	}
+
+	locales := tm.locales()
+	for _, u := range tm.sortedUnits() {
+		tu := tmxTU{TUID: u.ID, TUVs: []tmxTUV{{Lang: tm.SourceLocale, Seg: u.Source}}}
+		for _, l := range locales {
+			if t, ok := u.Targets[l]; ok {
+				tu.TUVs = append(tu.TUVs, tmxTUV{Lang: l, Seg: t})
+			}
+		}
+		doc.Units = append(doc.Units, tu)
+	}
+
+	b, err := xml.MarshalIndent(doc, "", "  ")
+	logAndQuitIfError(err)
+
+	fmt.Fprintln(w, xml.Header+string(b))
+}
+
+func readTMX(r io.Reader) (*translationMemory, error) {
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc tmxDocument
+	if err := xml.Unmarshal(b, &doc); err != nil {
+		return nil, err
+	}
+
+	tm := newTranslationMemory(doc.Header.SrcLang)
+	for i, tu := range doc.Units {
+		u := &tmUnit{ID: tu.TUID, Targets: map[string]string{}}
+		if u.ID == "" {
+			u.ID = fmt.Sprint(i)
+		}
+		for _, tuv := range tu.TUVs {
+			if tuv.Lang == tm.SourceLocale {
+				u.Source = tuv.Seg
+			} else {
+				u.Targets[tuv.Lang] = tuv.Seg
+			}
+		}
+		tm.units[u.ID] = u
+	}
+
+	return tm, nil
+}
+
+// The CSV format has a header row of "id" and locale columns, and a row per
+// unit. The first locale column is the source locale.
+
+func (tm *translationMemory) writeCSV(w io.Writer) {
+	locales := tm.locales()
+	rows := [][]string{append([]string{"id", tm.SourceLocale}, locales...)}
+	for _, u := range tm.sortedUnits() {
+		row := []string{u.ID, u.Source}
+		for _, l := range locales {
+			row = append(row, u.Targets[l])
+		}
+		rows = append(rows, row)
+	}
+
+	logAndQuitIfError(csv.NewWriter(w).WriteAll(rows))
+}
+
+func readTMCSV(r io.Reader) (*translationMemory, error) {
+	rows, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 || len(rows[0]) < 2 {
+		return nil, fmt.Errorf("CSV must have a header row of id,<source locale>,<locale>...")
+	}
+
+	header := rows[0]
+	tm := newTranslationMemory(header[1])
+	for _, row := range rows[1:] {
+		u := &tmUnit{ID: row[0], Source: row[1], Targets: map[string]string{}}
+		for i := 2; i < len(header) && i < len(row); i++ {
+			if row[i] != "" {
+				u.Targets[header[i]] = row[i]
+			}
+		}
+		tm.units[u.ID] = u
+	}
+
+	return tm, nil
+}
+
+func (tm *translationMemory) write(filename, format string) {
+	f, err := os.Create(filename)
+	logAndQuitIfError(err)
+	defer f.Close()
+
+	if format == "csv" {
+		tm.writeCSV(f)
+	} else {
+		tm.writeTMX(f)
+	}
+}
+
+func tmFormat(c *cli.Context, filename string) string {
+	format, err := fileFormat(c, filename, "tmx", "csv")
+	logAndQuitIfError(err)
+
+	return format
+}
+
+var tmFormatFlag = cli.StringFlag{
+	Name:  "format",
+	Usage: "File format, tmx or csv. Defaults to the file extension, or tmx",
+}
+
+var TMCommand = cli.Command{
+	Name:   "tm",
+	Usage:  "export and import translation memory",
+	Before: cmdBefore,
+	Subcommands: []cli.Command{
+		tmExportCommand,
+		tmImportCommand,
+	},
+}
+
+var tmExportCommand = cli.Command{
+	Name:        "export",
+	Usage:       "exports the translations of the remote files as TMX or CSV",
+	Description: "export [--format tmx|csv] [--locale <locale>]... <file>",
+	Flags:       []cli.Flag{tmFormatFlag, localeFlag},
+	Action: func(c *cli.Context) {
+		if len(c.Args()) != 1 {
+			log.Println("Wrong number of arguments")
+			log.Fatalln("Usage: export [--format tmx|csv] [--locale <locale>]... <file>")
+		}
+
+		tm := exportTranslationMemory(localesOrAll(c))
+		tm.write(c.Args().Get(0), tmFormat(c, c.Args().Get(0)))
+		log.Printf("Exported %d translation units\n", len(tm.units))
+	},
+}
+
+// exportTranslationMemory aligns the strings of all the parseable remote
+// files with their translations
+func exportTranslationMemory(locales []string) *translationMemory {
+	tm := newTranslationMemory(sourceLocale())
+
+	files, err := client.ListAll(smartling.FilesListRequest{})
+	logAndQuitIfError(err)
+
+	var wg sync.WaitGroup
+	for _, f := range files {
+		if !isParseableFileType(f.FileType) {
+			continue
+		}
+
+		wg.Add(1)
+		go func(f smartling.File) {
+			defer wg.Done()
+
+			b, err := client.Download(f.FileURI)
+			logAndQuitIfError(err)
+			source, err := parseTranslations(b, f.FileType)
+			logAndQuitIfError(err)
+
+			for _, locale := range locales {
+				b, err := client.DownloadTranslation(locale, smartling.FileDownloadRequest{
+					FileURIRequest: smartling.FileURIRequest{FileURI: f.FileURI},
+				})
+				logAndQuitIfError(err)
+				translations, err := parseTranslations(b, f.FileType)
+				logAndQuitIfError(err)
+
+				tm.add(f.FileURI, source, translations, locale)
+			}
+		}(f)
+	}
+	wg.Wait()
+
+	return tm
+}
+
+var tmImportCommand = cli.Command{
+	Name:        "import",
+	Usage:       "imports translations from TMX or CSV as published translations",
+	Description: "import [--format tmx|csv] <file>",
+	Flags:       []cli.Flag{tmFormatFlag},
+	Action: func(c *cli.Context) {
+		if len(c.Args()) != 1 {
+			log.Println("Wrong number of arguments")
+			log.Fatalln("Usage: import [--format tmx|csv] <file>")
+		}
+
+		filename := c.Args().Get(0)
+		f, err := os.Open(filename)
+		logAndQuitIfError(err)
+		defer f.Close()
+
+		var tm *translationMemory
+		if tmFormat(c, filename) == "csv" {
+			tm, err = readTMCSV(f)
+		} else {
+			tm, err = readTMX(f)
+		}
+		logAndQuitIfError(err)
+
+		importTranslationMemory(tm, "/tm/"+strings.TrimSuffix(path.Base(filename), path.Ext(filename))+".json")
+	},
+}
+
+// importTranslationMemory uploads the source strings as a JSON file, then
+// imports each locale's translations of it as published translations so
+// they're added to the project's translation memory. Where units with the
+// same source have different translations, the unit with the lowest ID is
+// used.
+func importTranslationMemory(tm *translationMemory, remoteFile string) {
+	source := map[string]string{}
+	targets := map[string]map[string]string{}
+	chosen := map[string]string{}
+	for _, u := range tm.sortedUnits() {
+		id := tmStringID(u.Source)
+		source[id] = u.Source
+		for _, l := range sortedKeys(u.Targets) {
+			t := u.Targets[l]
+			if targets[l] == nil {
+				targets[l] = map[string]string{}
+			}
+			if existing, ok := targets[l][id]; ok {
+				if existing != t {
+					log.Printf("Using the %s translation of %q from %s, not %s\n", l, u.Source, chosen[l+id], u.ID)
+				}
+				continue
+			}
+			targets[l][id] = t
+			chosen[l+id] = u.ID
+		}
+	}
+
+	b, err := json.Marshal(source)
+	logAndQuitIfError(err)
+	r, err := client.Upload(&smartling.FileUploadRequest{
+		FileURIRequest: smartling.FileURIRequest{FileURI: remoteFile},
+		FileType:       smartling.FileTypeJSON,
+		File:           b,
+	})
+	logAndQuitIfError(err)
+	log.Printf("Uploaded %s (%d strings)\n", remoteFile, r.StringCount)
+
+	for _, locale := range tm.locales() {
+		b, err := json.Marshal(targets[locale])
+		logAndQuitIfError(err)
+
+		r, err := client.Import(locale, smartling.ImportRequest{
+			FileURIRequest:   smartling.FileURIRequest{FileURI: remoteFile},
+			File:             b,
+			FileType:         smartling.FileTypeJSON,
+			TranslationState: smartling.TranslationStatePublished,
+		})
+		logAndQuitIfError(err)
+		log.Printf("Imported %d %s translations\n", r.StringCount, locale)
+		for _, e := range r.TranslationImportErrors {
+			log.Println("  " + e)
+		}
+	}
+}
+
+// tmStringID identifies a source string, so identical strings are imported
+// once
+func tmStringID(s string) string {
+	h := sha1.Sum([]byte(s))
+	return hex.EncodeToString(h[:])[:12]
+}
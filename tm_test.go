@@ -0,0 +1,84 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestTranslationMemoryAddKeepsIdenticalTranslations(t *testing.T) {
+	tm := newTranslationMemory("en-US")
+	tm.add("file", map[string]string{"ok": "OK", "brand": "Acme", "missing": "Missing", "empty": ""},
+		map[string]string{"ok": "OK", "brand": "Acme", "empty": ""}, "de-DE")
+
+	for _, id := range []string{"file#ok", "file#brand"} {
+		if u, ok := tm.units[id]; !ok || u.Targets["de-DE"] != u.Source {
+			t.Errorf("expected %s to be kept", id)
+		}
+	}
+	for _, id := range []string{"file#missing", "file#empty"} {
+		if _, ok := tm.units[id]; ok {
+			t.Errorf("expected %s to be skipped", id)
+		}
+	}
+}
+
+func TestImportTranslationMemoryChoosesDuplicatesByID(t *testing.T) {
+	f := newFakeSmartling(t)
+	f.use(t, &Config{})
+	f.handle("POST", "/files-api/v2/projects/project/file", func(r fakeRequest) interface{} {
+		return map[string]interface{}{"stringCount": 1}
+	})
+	importEndpoint := "/files-api/v2/projects/project/locales/de-DE/file/import"
+	f.handle("POST", importEndpoint, func(r fakeRequest) interface{} {
+		return map[string]interface{}{"stringCount": 1}
+	})
+
+	// map order is random, so import several times
+	for i := 0; i < 10; i++ {
+		tm := newTranslationMemory("en-US")
+		for _, u := range []*tmUnit{
+			{ID: "b", Source: "Save", Targets: map[string]string{"de-DE": "Sichern"}},
+			{ID: "a", Source: "Save", Targets: map[string]string{"de-DE": "Speichern"}},
+		} {
+			tm.units[u.ID] = u
+		}
+		importTranslationMemory(tm, "/tm/test.json")
+	}
+
+	for _, r := range f.Requests("POST", importEndpoint) {
+		if !bytes.Contains(r.Body, []byte("Speichern")) || bytes.Contains(r.Body, []byte("Sichern")) {
+			t.Fatalf("expected the translation of unit a to be imported, got:\n%s", r.Body)
+		}
+	}
+}
+
+func TestPullAndExportUseTheSameUnitIDs(t *testing.T) {
+	chdir(t, t.TempDir())
+	writeTestFile(t, "en.json", `{"a": "A"}`)
+	f := newFakeSmartling(t)
+	f.use(t, &Config{path: ".", FileGlobs: []string{"en.json"}, PullFilePath: "{{.Locale}}.json"})
+	f.handle("GET", "/files-api/v2/projects/project/files/list", func(r fakeRequest) interface{} {
+		return map[string]interface{}{"totalCount": 1, "items": []map[string]string{{"fileUri": "/en.json", "fileType": "json"}}}
+	})
+	f.handle("GET", "/projects-api/v2/projects/project", func(r fakeRequest) interface{} {
+		return map[string]string{"sourceLocaleId": "en-US"}
+	})
+	f.handle("GET", "/files-api/v2/projects/project/file", func(r fakeRequest) interface{} {
+		return []byte(`{"a": "A"}`)
+	})
+	f.handle("GET", "/files-api/v2/projects/project/locales/de-DE/file", func(r fakeRequest) interface{} {
+		return []byte(`{"a": "A de"}`)
+	})
+
+	pulledTranslations = newTranslationMemory("en-US")
+	defer func() { pulledTranslations = nil }()
+	captureStdout(t, func() { pullProjectFile("en.json", "de-DE", "") })
+	exported := exportTranslationMemory([]string{"de-DE"})
+
+	if _, ok := pulledTranslations.units["/en.json#a"]; !ok {
+		t.Errorf("got pulled units %v, want /en.json#a", pulledTranslations.units)
+	}
+	if _, ok := exported.units["/en.json#a"]; !ok {
+		t.Errorf("got exported units %v, want /en.json#a", exported.units)
+	}
+}
@@ -3,14 +3,17 @@ package main
 import (
 	"crypto/sha1"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"log"
+	"net/url"
 	"os"
 	"os/user"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/99designs/api-sdk-go"
@@ -52,11 +55,32 @@ func projectFileHash(projectFilepath string) string {
 	return h[:7] // truncate to 7 chars
 }
 
-func translateProjectFile(projectFilepath, locale, prefix string) (hit bool, b []byte, err error) {
+func cacheFilePath(projectFilepath, locale string) string {
+	name := fmt.Sprintf("%s.%s", projectFileHash(projectFilepath), locale)
+	if ProjectConfig.name != "" {
+		// the same file can be translated differently in each project
+		name = ProjectConfig.name + "." + name
+	}
 
-	hash := projectFileHash(projectFilepath)
+	return filepath.Join(cachePath, name)
+}
+
+func clearCachedTranslations(projectFilepath, locale string) {
+	if dryRun {
+		return
+	}
 
-	cacheFilePath := filepath.Join(cachePath, fmt.Sprintf("%s.%s", hash, locale))
+	for _, f := range []string{cacheFilePath(projectFilepath, locale), cacheFilePath(projectFilepath, locale) + ".translated"} {
+		err := os.Remove(f)
+		if err != nil && !os.IsNotExist(err) {
+			logAndQuitIfError(err)
+		}
+	}
+}
+
+func translateProjectFile(projectFilepath, locale, prefix string) (hit bool, b []byte, err error) {
+
+	cacheFilePath := cacheFilePath(projectFilepath, locale)
 
 	// check cache
 	hit, b = getCachedTranslations(cacheFilePath)
@@ -71,6 +95,10 @@ func translateProjectFile(projectFilepath, locale, prefix string) (hit bool, b [
 	}
 
 	// write to cache
+	if dryRun {
+		printDryRun("write %s", cacheFilePath)
+		return
+	}
 	err = ioutil.WriteFile(cacheFilePath, b, 0644)
 	if err != nil {
 		return
@@ -93,8 +121,16 @@ func getCachedTranslations(cacheFilePath string) (hit bool, b []byte) {
 	return
 }
 
+// pushLocks stops goroutines pulling a file in several locales from all
+// pushing it
+var pushLocks sync.Map
+
 func findIdenticalRemoteFileOrPush(projectFilepath, prefix string) string {
 	remoteFile := projectFileRemoteName(projectFilepath, prefix)
+	lock, _ := pushLocks.LoadOrStore(remoteFile, &sync.Mutex{})
+	lock.(*sync.Mutex).Lock()
+	defer lock.(*sync.Mutex).Unlock()
+
 	allRemoteFiles := getRemoteFileList()
 
 	if allRemoteFiles.contains(remoteFile) {
@@ -112,9 +148,20 @@ func findIdenticalRemoteFileOrPush(projectFilepath, prefix string) string {
 	return pushProjectFile(projectFilepath, prefix)
 }
 
+// errNotPushed is returned in a dry run for files that would be pushed
+// before they're translated
+var errNotPushed = errors.New("the file hasn't been pushed")
+
 func translateViaSmartling(projectFilepath, prefix, locale string) (b []byte, err error) {
 	remotePath := findIdenticalRemoteFileOrPush(projectFilepath, prefix)
 
+	if dryRun && !getRemoteFileList().contains(remotePath) {
+		// the file would have been pushed, so there's nothing to download
+		params := url.Values{"fileUri": {remotePath}}
+		printDryRunRequest("GET", client.filesEndpoint("/locales/"+locale+"/file"), params, nil, "")
+		return nil, errNotPushed
+	}
+
 	b, err = client.DownloadTranslation(locale, smartling.FileDownloadRequest{
 		FileURIRequest: smartling.FileURIRequest{FileURI: remotePath},
 	})
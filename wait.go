@@ -0,0 +1,117 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/urfave/cli"
+)
+
+// exit status when translations weren't completed in time
+const exitCodeTimeout = 2
+
+var projectWaitCommand = cli.Command{
+	Name:  "wait",
+	Usage: "wait until the project's remote files are translated",
+	Flags: []cli.Flag{
+		prefixFlag,
+		cli.DurationFlag{
+			Name:  "timeout",
+			Value: 2 * time.Hour,
+			Usage: "Give up after this long",
+		},
+		cli.DurationFlag{
+			Name:  "interval",
+			Value: 5 * time.Minute,
+			Usage: "Time between status checks",
+		},
+		cli.StringSliceFlag{
+			Name:  "locale",
+			Usage: "Locale to wait for, can be repeated. Defaults to all locales",
+		},
+		cli.BoolFlag{
+			Name:  "pull",
+			Usage: "Pull the files for each locale as soon as it's complete",
+		},
+	},
+	Action: func(c *cli.Context) {
+		if len(c.Args()) > 0 {
+			log.Println("Wrong number of arguments")
+			log.Fatalln("Usage: wait")
+		}
+
+		deadline := time.Now().Add(c.Duration("timeout"))
+		forEachProject(func() {
+			prefix := prefixOrGitPrefix(c.String("prefix"))
+			locales := c.StringSlice("locale")
+			if len(locales) == 0 {
+				locales = fetchLocales()
+			}
+
+			if !waitForProjectTranslations(prefix, locales, time.Until(deadline), c.Duration("interval"), c.Bool("pull")) {
+				log.Printf("Timed out after %s waiting for translations\n", c.Duration("timeout"))
+				os.Exit(exitCodeTimeout)
+			}
+		})
+	},
+}
+
+// waitForProjectTranslations polls the project status until all the locales
+// are complete, returning false if the timeout is reached first
+func waitForProjectTranslations(prefix string, locales []string, timeout, interval time.Duration, pull bool) bool {
+	deadline := time.Now().Add(timeout)
+	done := map[string]bool{}
+
+	// resolve the remote files once, so polling doesn't push anything
+	remoteFiles := projectRemoteFiles(prefix)
+
+	for {
+		statuses, err := fetchProjectStatus(remoteFiles)
+		if err != nil {
+			log.Println("Couldn't check the status, retrying:", err)
+		} else {
+			progress := []string{}
+			for _, locale := range locales {
+				p := statuses.LocaleCompletedPercent(locale)
+				progress = append(progress, fmt.Sprintf("%s %.0f%%", locale, p))
+
+				if p >= 100 && !done[locale] {
+					done[locale] = true
+					if pull {
+						pullProjectLocale(prefix, locale)
+					}
+				}
+			}
+			log.Println(time.Now().Format("15:04:05"), strings.Join(progress, "  "))
+
+			if len(done) == len(locales) {
+				log.Println("All translations are complete")
+				return true
+			}
+		}
+
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return false
+		}
+
+		// sleep no longer than the time left, then check one last time
+		sleep := interval
+		if remaining < sleep {
+			sleep = remaining
+		}
+		time.Sleep(sleep)
+	}
+}
+
+// pullProjectLocale pulls all project files for a locale, bypassing the cache
+// as it may hold incomplete translations
+func pullProjectLocale(prefix, locale string) {
+	for _, projectFilepath := range ProjectConfig.Files() {
+		clearCachedTranslations(projectFilepath, locale)
+		pullProjectFile(projectFilepath, locale, prefix)
+	}
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/99designs/api-sdk-go"
+	"github.com/fsnotify/fsnotify"
+)
+
+// watchDebounce is how long to wait for changes to settle before pushing,
+// as editors often write a file several times when saving
+const watchDebounce = 500 * time.Millisecond
+
+// watchProjectFiles pushes project files whenever their content changes,
+// and pulls their translations if retrievalType is set. It runs until the
+// process is interrupted.
+func watchProjectFiles(prefix string, retrievalType smartling.RetrievalType) {
+	watcher, err := fsnotify.NewWatcher()
+	logAndQuitIfError(err)
+	defer watcher.Close()
+
+	// watch directories rather than files, so files replaced by editors
+	// when saving are still seen
+	watched := map[string]string{}
+	hashes := map[string]string{}
+	for _, projectFilepath := range ProjectConfig.Files() {
+		localpath, err := filepath.Abs(localRelativeFilePath(projectFilepath))
+		logAndQuitIfError(err)
+
+		watched[localpath] = projectFilepath
+		hashes[projectFilepath] = projectFileHash(projectFilepath)
+	}
+	dirs := map[string]bool{}
+	for localpath := range watched {
+		dir := filepath.Dir(localpath)
+		if !dirs[dir] {
+			logAndQuitIfError(watcher.Add(dir))
+			dirs[dir] = true
+		}
+	}
+
+	var locales []string
+	if retrievalType != smartling.RetrieveDefault {
+		locales = fetchLocales()
+	}
+
+	log.Printf("Watching %d files for changes\n", len(watched))
+
+	changed := map[string]int{}
+	var settled <-chan time.Time
+	for {
+		select {
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+			if projectFilepath, ok := watched[event.Name]; ok && event.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Rename) != 0 {
+				changed[projectFilepath]++
+				settled = time.After(watchDebounce)
+			}
+
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+			log.Println("Error watching files:", err.Error())
+
+		case <-settled:
+			for _, projectFilepath := range sortedIntKeys(changed) {
+				if _, err := os.Stat(localRelativeFilePath(projectFilepath)); err != nil {
+					continue
+				}
+
+				h := projectFileHash(projectFilepath)
+				if h == hashes[projectFilepath] {
+					continue
+				}
+
+				// keep watching if the push fails, and push it again on the
+				// next change
+				remoteFile, err := uploadProjectFile(projectFilepath, prefix)
+				if err != nil {
+					log.Println("Error pushing", projectFilepath, err.Error())
+					continue
+				}
+				hashes[projectFilepath] = h
+				for _, locale := range locales {
+					pullWatchedFile(projectFilepath, remoteFile, locale, retrievalType)
+				}
+			}
+			changed = map[string]int{}
+			settled = nil
+		}
+	}
+}
+
+// pullWatchedFile writes the pseudo or pending translations of a pushed file
+func pullWatchedFile(projectFilepath, remoteFile, locale string, retrievalType smartling.RetrievalType) {
+	fp := localPullFilePath(projectFilepath, locale)
+	if dryRun {
+		printDryRun("write %s", fp)
+		return
+	}
+
+	b, err := client.DownloadTranslation(locale, smartling.FileDownloadRequest{
+		FileURIRequest: smartling.FileURIRequest{FileURI: remoteFile},
+		Type:           retrievalType,
+	})
+	if err != nil {
+		log.Println("Error downloading", remoteFile, err.Error())
+		return
+	}
+
+	if err := ioutil.WriteFile(fp, b, 0644); err != nil {
+		log.Println("Error writing", fp, err.Error())
+		return
+	}
+	fmt.Println("Wrote", fp)
+}